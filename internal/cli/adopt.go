@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/1outres/cldenv/internal/context"
+	"github.com/1outres/cldenv/pkg/textdiff"
+)
+
+var adoptDryRun bool
+
+// adoptCmd represents the adopt command
+var adoptCmd = &cobra.Command{
+	Use:   "adopt",
+	Short: "Move edits that replaced a link into the active context",
+	Long: `Editors and Claude Code itself may save ~/.claude/settings.json by writing a
+new file over the symbolic link, so the edit never reaches the context. adopt
+detects files that replaced their links, shows how they differ from the
+active context's copy, moves the new content into ~/.cldenv/<active>/ and
+restores the links.`,
+	Args: cobra.NoArgs,
+	Annotations: map[string]string{
+		skipDriftWarningAnnotation: "true",
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		manager, err := context.NewManager()
+		if err != nil {
+			return fmt.Errorf("failed to create context manager: %w", err)
+		}
+
+		drifts := manager.DetectDrift()
+		if len(drifts) == 0 {
+			printSuccess("✓ Nothing to adopt\n")
+		}
+
+		results := []adoptResult{}
+		var names []string
+		for _, drift := range drifts {
+			// Diffs are only shown to people; scripts get the list of files
+			if humanOutput() {
+				printDriftDiff(manager, drift)
+			}
+			names = append(names, drift.Artifact.DisplayName())
+
+			if adoptDryRun {
+				results = append(results, adoptResult{File: drift.Artifact.DisplayName(), Context: drift.Context})
+				continue
+			}
+
+			if err := manager.Adopt(drift); err != nil {
+				return fmt.Errorf("failed to adopt %s: %w", drift.Artifact.DisplayName(), err)
+			}
+			printSuccess("✓ Adopted %s into context '%s'\n", drift.Artifact.DisplayName(), drift.Context)
+			results = append(results, adoptResult{File: drift.Artifact.DisplayName(), Context: drift.Context, Adopted: true})
+		}
+
+		return printResult(results, names)
+	},
+}
+
+// adoptResult is the machine-readable output of the adopt command, one per replaced link
+type adoptResult struct {
+	// File is the managed artifact, e.g. settings.json
+	File    string `json:"file"`
+	Context string `json:"context"`
+	// Adopted is false with --dry-run
+	Adopted bool `json:"adopted"`
+}
+
+func init() {
+	adoptCmd.Flags().BoolVar(&adoptDryRun, "dry-run", false, "show what would be adopted without changing anything")
+}
+
+// printDriftDiff shows how a drifted artifact differs from the context's copy
+func printDriftDiff(manager *context.Manager, drift context.Drift) {
+	if drift.Artifact.Dir {
+		fmt.Printf("%s was replaced by a directory; it will replace %s\n", drift.ClaudePath, drift.ContextPath)
+		return
+	}
+	// Never print secrets
+	if drift.Artifact.Sensitive {
+		fmt.Printf("%s was replaced by a new login; it will replace %s\n", drift.ClaudePath, drift.ContextPath)
+		return
+	}
+
+	current, err := os.ReadFile(drift.ContextPath)
+	if err != nil && !os.IsNotExist(err) {
+		fmt.Printf("! failed to read %s: %v\n", drift.ContextPath, err)
+		return
+	}
+
+	// Secrets in resolved settings show as their placeholders
+	replaced, err := manager.DriftContent(drift)
+	if err != nil {
+		fmt.Printf("! %v\n", err)
+		return
+	}
+
+	diff := textdiff.Unified(drift.ContextPath, drift.ClaudePath, string(current), string(replaced))
+	if diff == "" {
+		fmt.Printf("%s replaced its link without changes\n", drift.ClaudePath)
+		return
+	}
+	fmt.Print(diff)
+}
@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/1outres/cldenv/internal/context"
+)
+
+var copyForce bool
+
+// copyCmd represents the copy command
+var copyCmd = &cobra.Command{
+	Use:   "copy <src> <dst>",
+	Short: "Copy a context",
+	Long: `Create a new context with a copy of the files of an existing one, including
+its parent and merge settings. This is the same as 'cldenv create <dst> --from <src>'.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		src, dst := args[0], args[1]
+
+		manager, err := context.NewManager()
+		if err != nil {
+			return fmt.Errorf("failed to create context manager: %w", err)
+		}
+
+		if !manager.ContextExists(src) {
+			return fmt.Errorf("%w: '%s'", context.ErrContextNotFound, src)
+		}
+
+		issues, err := manager.ValidateContext(src)
+		if err != nil {
+			return fmt.Errorf("failed to validate context '%s': %w", src, err)
+		}
+		if err := checkValidation(issues, copyForce); err != nil {
+			return err
+		}
+
+		if err := manager.CreateContext(dst, context.CreateOptions{From: src}); err != nil {
+			return fmt.Errorf("failed to copy context '%s': %w", src, err)
+		}
+
+		printSuccess("✓ Copied context '%s' to '%s'\n", src, dst)
+		return printContextResult(manager, dst)
+	},
+}
+
+func init() {
+	copyCmd.Flags().BoolVar(&copyForce, "force", false, "copy even if settings.json is invalid")
+}
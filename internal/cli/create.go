@@ -4,15 +4,36 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
+	"github.com/1outres/cldenv/internal/config"
 	"github.com/1outres/cldenv/internal/context"
+	"github.com/1outres/cldenv/internal/schema"
+)
+
+var (
+	createExtends     string
+	createFrom        string
+	createTemplate    string
+	createVars        map[string]string
+	createForce       bool
+	createDescription string
+	createTags        []string
+	createOwner       string
 )
 
 // createCmd represents the create command
 var createCmd = &cobra.Command{
 	Use:   "create <context>",
 	Short: "Create a new context",
-	Long: `Create a new context directory with empty configuration files.
-After creating a context, you can switch to it using 'cldenv use <context>'.`,
+	Long: `Create a new context with an empty settings.json and a starter CLAUDE.md.
+After creating a context, you can switch to it using 'cldenv use <context>'.
+
+With --from, the new context is a copy of an existing one. With --template, it
+starts from a built-in template (basic, strict, permissive, reviewer) or from a
+directory in ~/.cldenv/.templates/. Template files can use variables such as
+{{.context}}, {{.user}} and {{.date}}, and any set with --var key=value.
+
+With --extends, the context inherits from a parent: settings.json is deep merged
+onto the parent's and CLAUDE.md is appended to the parent's when switching.`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		contextName := args[0]
@@ -28,31 +49,85 @@ After creating a context, you can switch to it using 'cldenv use <context>'.`,
 
 		// Check if context already exists
 		if manager.ContextExists(contextName) {
-			fmt.Printf("Context '%s' already exists.\n\n", contextName)
-			
-			// Show available contexts
-			contexts := manager.GetContexts()
-			if len(contexts) > 0 {
-				fmt.Println("Existing contexts:")
-				for _, ctx := range contexts {
-					marker := "  "
-					if ctx.Name == manager.GetActiveContext() {
-						marker = "* "
-					}
-					fmt.Printf("%s%s\n", marker, ctx.Name)
+			printHint("Use 'cldenv use %s' to switch to it or 'cldenv create <context>' with another name\n", contextName)
+			return fmt.Errorf("%w: '%s'", context.ErrContextAlreadyExists, contextName)
+		}
+
+		// Check the parent before creating anything
+		if createExtends != "" && !manager.ContextExists(createExtends) {
+			return fmt.Errorf("%w: parent '%s'", context.ErrContextNotFound, createExtends)
+		}
+
+		if createFrom != "" && createTemplate != "" {
+			return fmt.Errorf("--from and --template cannot be used together")
+		}
+
+		opts := context.CreateOptions{
+			From:        createFrom,
+			Extends:     createExtends,
+			Description: createDescription,
+			Tags:        createTags,
+			Owner:       createOwner,
+		}
+		if opts.Owner == "" {
+			opts.Owner = currentUser()
+		}
+		switch {
+		case createFrom != "":
+			if !manager.ContextExists(createFrom) {
+				return fmt.Errorf("%w: '%s'", context.ErrContextNotFound, createFrom)
+			}
+			issues, err := manager.ValidateContext(createFrom)
+			if err != nil {
+				return fmt.Errorf("failed to validate context '%s': %w", createFrom, err)
+			}
+			if err := checkValidation(issues, createForce); err != nil {
+				return err
+			}
+		case createTemplate != "":
+			files, err := manager.RenderTemplate(createTemplate, contextName, createVars)
+			if err != nil {
+				return err
+			}
+			var issues []context.ValidationIssue
+			if settings, ok := files[config.SettingsFile]; ok {
+				for _, issue := range schema.ValidateSettings(settings) {
+					issues = append(issues, context.ValidationIssue{Context: contextName, Issue: issue})
 				}
 			}
-			
-			return nil
+			if err := checkValidation(issues, createForce); err != nil {
+				return err
+			}
+			opts.Files = files
 		}
 
 		// Create the context
-		if err := manager.CreateContext(contextName); err != nil {
+		if err := manager.CreateContext(contextName, opts); err != nil {
 			return fmt.Errorf("failed to create context '%s': %w", contextName, err)
 		}
 
-		fmt.Printf("✓ Created context '%s'\n", contextName)
-		fmt.Printf("Use 'cldenv use %s' to switch to this context\n", contextName)
-		return nil
+		printSuccess("✓ Created context '%s'\n", contextName)
+		switch {
+		case createFrom != "":
+			printSuccess("  copied from '%s'\n", createFrom)
+		case createTemplate != "":
+			printSuccess("  from template '%s'\n", createTemplate)
+		}
+		if createExtends != "" {
+			printSuccess("  inherits from '%s'\n", createExtends)
+		}
+		printHint("Use 'cldenv use %s' to switch to this context\n", contextName)
+		return printContextResult(manager, contextName)
 	},
+}
+
+func init() {
+	createCmd.Flags().StringVar(&createExtends, "extends", "", "parent context to inherit settings.json and CLAUDE.md from")
+	createCmd.Flags().StringVar(&createFrom, "from", "", "context to copy")
+	createCmd.Flags().StringVar(&createTemplate, "template", "", "template to start from")
+	createCmd.Flags().StringToStringVar(&createVars, "var", nil, "template variable as key=value (repeatable)")
+	createCmd.Flags().BoolVar(&createForce, "force", false, "create even if settings.json is invalid")
+	createCmd.Flags().StringVar(&createDescription, "description", "", "what the context is for")
+	createCmd.Flags().StringSliceVar(&createTags, "tag", nil, "tag to group the context by (repeatable)")
+	createCmd.Flags().StringVar(&createOwner, "owner", "", "person responsible for the context (default: current user)")
 }
\ No newline at end of file
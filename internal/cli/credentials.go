@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/1outres/cldenv/internal/context"
+)
+
+// credentialsCmd represents the credentials command
+var credentialsCmd = &cobra.Command{
+	Use:   "credentials",
+	Short: "Give contexts their own Claude account login",
+	Long: `Give contexts their own Claude account login.
+
+A context carrying .credentials.json logs in with that account while it is
+active: the file is linked into ~/.claude like the other managed files. The
+login no context owns is put aside meanwhile and restored when switching to a
+context without credentials. Logins refreshed or changed by Claude Code are
+adopted into the active context.
+
+Credentials are kept with mode 0600 and are never recorded in history, synced,
+exported or shown in diffs.`,
+	Example: `  cldenv use client-x
+  claude /login
+  cldenv credentials save client-x`,
+}
+
+// credentialsSaveCmd represents the credentials save command
+var credentialsSaveCmd = &cobra.Command{
+	Use:   "save <context>",
+	Short: "Save the current login into a context",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		manager, err := context.NewManager()
+		if err != nil {
+			return fmt.Errorf("failed to create context manager: %w", err)
+		}
+
+		if err := manager.SaveCredentials(args[0]); err != nil {
+			return fmt.Errorf("failed to save credentials into '%s': %w", args[0], err)
+		}
+
+		printSuccess("✓ Context '%s' now logs in with the current account\n", args[0])
+		return printResult(credentialsResult{Context: args[0], Credentials: true}, []string{args[0]})
+	},
+}
+
+// credentialsRemoveCmd represents the credentials remove command
+var credentialsRemoveCmd = &cobra.Command{
+	Use:   "remove <context>",
+	Short: "Remove the login of a context",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		manager, err := context.NewManager()
+		if err != nil {
+			return fmt.Errorf("failed to create context manager: %w", err)
+		}
+
+		if err := manager.RemoveCredentials(args[0]); err != nil {
+			return fmt.Errorf("failed to remove credentials of '%s': %w", args[0], err)
+		}
+
+		printSuccess("✓ Context '%s' no longer has its own login\n", args[0])
+		return printResult(credentialsResult{Context: args[0], Credentials: false}, []string{args[0]})
+	},
+}
+
+// credentialsResult is the machine-readable output of the credentials commands
+type credentialsResult struct {
+	Context string `json:"context"`
+	// Credentials reports whether the context now carries its own login
+	Credentials bool `json:"credentials"`
+}
+
+func init() {
+	credentialsCmd.AddCommand(credentialsSaveCmd)
+	credentialsCmd.AddCommand(credentialsRemoveCmd)
+}
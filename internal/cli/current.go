@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/1outres/cldenv/internal/context"
+)
+
+// currentCmd represents the current command
+var currentCmd = &cobra.Command{
+	Use:   "current",
+	Short: "Show the active and directory-requested contexts",
+	Long: `Show the globally active context (the one ~/.claude/ links to) and the
+context requested by a .cldenv-context file in the current directory or its
+parents, flagging a mismatch between the two.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		printer, err := newPrinter()
+		if err != nil {
+			return err
+		}
+
+		manager, err := context.NewManager()
+		if err != nil {
+			return fmt.Errorf("failed to create context manager: %w", err)
+		}
+
+		activeContext := manager.GetActiveContext()
+		local, err := findLocalContext()
+		if err != nil {
+			return err
+		}
+
+		status := currentStatus{Active: activeContext, Directory: local}
+		var names []string
+		if activeContext != "" {
+			names = append(names, activeContext)
+		}
+
+		return printer.Print(status, names, func() {
+			if activeContext == "" {
+				fmt.Println("Active:    (none)")
+			} else {
+				fmt.Printf("Active:    %s\n", activeContext)
+			}
+
+			if local == nil {
+				fmt.Println("Directory: (none)")
+				return
+			}
+
+			fmt.Printf("Directory: %s (set by %s)\n", local.Name, local.File)
+			printLocalMismatch(manager, activeContext, local)
+		})
+	},
+}
+
+// currentStatus is the machine-readable output of the current command
+type currentStatus struct {
+	// Active is the globally active context, empty if none is
+	Active string `json:"active"`
+	// Directory is the context requested by the current directory, if any
+	Directory *context.LocalContext `json:"directory"`
+}
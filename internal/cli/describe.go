@@ -0,0 +1,152 @@
+package cli
+
+import (
+	"fmt"
+	"os/user"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/1outres/cldenv/internal/context"
+)
+
+var (
+	describeDescription string
+	describeTags        []string
+	describeUntags      []string
+	describeOwner       string
+)
+
+// describeCmd represents the describe command
+var describeCmd = &cobra.Command{
+	Use:   "describe <context>",
+	Short: "Show or change the description, tags and owner of a context",
+	Long: `Show the metadata of a context: description, tags, owner, parent, and when it
+was created and last used. With flags, change the description, tags or owner first.`,
+	Example: `  cldenv describe work
+  cldenv describe work --description "Client A backend" --tag client-a --tag go`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		contextName := args[0]
+
+		printer, err := newPrinter()
+		if err != nil {
+			return err
+		}
+
+		manager, err := context.NewManager()
+		if err != nil {
+			return fmt.Errorf("failed to create context manager: %w", err)
+		}
+
+		flags := cmd.Flags()
+		if flags.Changed("description") || flags.Changed("tag") || flags.Changed("untag") || flags.Changed("owner") {
+			err := manager.UpdateMetadata(contextName, func(meta *context.Metadata) error {
+				if flags.Changed("description") {
+					meta.Description = describeDescription
+				}
+				if flags.Changed("owner") {
+					meta.Owner = describeOwner
+				}
+				for _, tag := range describeTags {
+					if err := context.ValidateTag(tag); err != nil {
+						return err
+					}
+					if !meta.HasTag(tag) {
+						meta.Tags = append(meta.Tags, tag)
+					}
+				}
+				meta.Tags = slices.DeleteFunc(meta.Tags, func(tag string) bool {
+					return slices.Contains(describeUntags, tag)
+				})
+				return nil
+			})
+			if err != nil {
+				return fmt.Errorf("failed to update context '%s': %w", contextName, err)
+			}
+		}
+
+		if err := manager.LoadContexts(); err != nil {
+			return fmt.Errorf("failed to load contexts: %w", err)
+		}
+		ctx, err := findContext(manager, contextName)
+		if err != nil {
+			return err
+		}
+
+		return printer.Print(ctx, []string{ctx.Name}, func() {
+			fmt.Printf("Name:        %s\n", ctx.Name)
+			fmt.Printf("Description: %s\n", valueOr(ctx.Description, "(none)"))
+			fmt.Printf("Tags:        %s\n", valueOr(strings.Join(ctx.Tags, ", "), "(none)"))
+			fmt.Printf("Owner:       %s\n", valueOr(ctx.Owner, "(none)"))
+			if ctx.Extends != "" {
+				fmt.Printf("Extends:     %s\n", ctx.Extends)
+			}
+			fmt.Printf("Created:     %s\n", formatTime(ctx.Created, "unknown"))
+			fmt.Printf("Last used:   %s\n", formatTime(ctx.LastUsed, "never"))
+			if ctx.IsActive {
+				fmt.Println("Status:      active")
+			}
+		})
+	},
+}
+
+// valueOr returns s, or fallback if s is empty
+func valueOr(s, fallback string) string {
+	if s == "" {
+		return fallback
+	}
+	return s
+}
+
+// formatTime formats a timestamp in local time, or returns fallback for the zero time
+func formatTime(t time.Time, fallback string) string {
+	if t.IsZero() {
+		return fallback
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+// relativeTime describes how long ago t was, e.g. "3 hours ago", or returns
+// fallback for the zero time
+func relativeTime(t time.Time, fallback string) string {
+	if t.IsZero() {
+		return fallback
+	}
+
+	plural := func(n int, unit string) string {
+		if n == 1 {
+			return fmt.Sprintf("1 %s ago", unit)
+		}
+		return fmt.Sprintf("%d %ss ago", n, unit)
+	}
+
+	switch d := time.Since(t); {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return plural(int(d/time.Minute), "minute")
+	case d < 24*time.Hour:
+		return plural(int(d/time.Hour), "hour")
+	case d < 30*24*time.Hour:
+		return plural(int(d/(24*time.Hour)), "day")
+	default:
+		return t.Local().Format("2006-01-02")
+	}
+}
+
+// currentUser returns the login name of the current user
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return ""
+}
+
+func init() {
+	describeCmd.Flags().StringVar(&describeDescription, "description", "", "set the description")
+	describeCmd.Flags().StringSliceVar(&describeTags, "tag", nil, "add a tag (repeatable)")
+	describeCmd.Flags().StringSliceVar(&describeUntags, "untag", nil, "remove a tag (repeatable)")
+	describeCmd.Flags().StringVar(&describeOwner, "owner", "", "set the owner")
+}
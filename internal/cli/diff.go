@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/1outres/cldenv/internal/context"
+	"github.com/1outres/cldenv/internal/output"
+	"github.com/1outres/cldenv/pkg/jsondiff"
+)
+
+var (
+	diffNameOnly bool
+	diffJSON     bool
+)
+
+// diffCmd represents the diff command
+var diffCmd = &cobra.Command{
+	Use:   "diff <context>@<rev> | diff <a> <b>",
+	Short: "Show differences between contexts or revisions",
+	Long: `Show how the files of a context changed since a revision listed by
+'cldenv history <context>', or compare two sides. A side is a context, a
+revision written as <context>@<rev>, or a directory such as ~/.claude to
+compare against the working state.
+
+JSON files are compared key by key, so reordered keys are not reported and
+changes are shown by key path, e.g. permissions.allow[3]. Other files are shown
+as a unified diff.`,
+	Example: `  cldenv diff work@3f2a1c
+  cldenv diff default work
+  cldenv diff ~/.claude work --name-only`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if diffNameOnly && diffJSON {
+			return fmt.Errorf("--name-only and --json cannot be used together")
+		}
+
+		// --json and --name-only predate --output and are kept as shorthands
+		format := outputFormat
+		switch {
+		case diffJSON:
+			format = string(output.JSON)
+		case diffNameOnly:
+			format = string(output.Name)
+		}
+		printer, err := output.NewPrinter(os.Stdout, format, outputTemplate)
+		if err != nil {
+			return err
+		}
+
+		manager, err := context.NewManager()
+		if err != nil {
+			return fmt.Errorf("failed to create context manager: %w", err)
+		}
+
+		aName, bName := args[0], ""
+		if len(args) == 2 {
+			bName = args[1]
+		} else {
+			contextName, _, ok := context.ParseRevision(args[0])
+			if !ok {
+				return fmt.Errorf("expected <context>@<rev> or two sides to compare, got '%s'", args[0])
+			}
+			bName = contextName
+		}
+
+		a, b, err := manager.ReadSides(aName, bName)
+		if err != nil {
+			return err
+		}
+
+		diffs := context.CompareFiles(aName, bName, a, b)
+		if diffs == nil {
+			diffs = []context.FileDiff{}
+		}
+		paths := make([]string, len(diffs))
+		for i, d := range diffs {
+			paths[i] = d.Path
+		}
+
+		return printer.Print(diffs, paths, func() {
+			printFileDiffs(aName, bName, diffs)
+		})
+	},
+}
+
+// printFileDiffs prints key path changes for JSON files and unified diffs for the rest
+func printFileDiffs(aName, bName string, diffs []context.FileDiff) {
+	for _, d := range diffs {
+		if d.Unified != "" {
+			fmt.Print(d.Unified)
+			continue
+		}
+
+		fmt.Printf("--- %s/%s\n+++ %s/%s\n", aName, d.Path, bName, d.Path)
+		for _, change := range d.Changes {
+			fmt.Println(jsondiff.Format(change))
+		}
+	}
+}
+
+func init() {
+	diffCmd.Flags().BoolVar(&diffNameOnly, "name-only", false, "only list the files that differ, same as --output name")
+	diffCmd.Flags().BoolVar(&diffJSON, "json", false, "print the differences as JSON, same as --output json")
+}
@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/1outres/cldenv/internal/context"
+)
+
+var doctorFix bool
+
+// doctorCmd represents the doctor command
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Detect and repair broken cldenv state",
+	Long: `Check ~/.claude and ~/.cldenv for broken state: files pointing at different
+contexts, dangling links, regular files that replaced links, invalid
+settings.json, contexts missing files, stray backups and links pointing outside
+~/.cldenv. With --fix, repair what can be repaired safely.
+
+The command exits with a non-zero status if errors remain.`,
+	Args:         cobra.NoArgs,
+	Annotations: map[string]string{
+		skipInitAnnotation:         "true",
+		skipDriftWarningAnnotation: "true",
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		printer, err := newPrinter()
+		if err != nil {
+			return err
+		}
+
+		manager, err := context.NewManager()
+		if err != nil {
+			return fmt.Errorf("failed to create context manager: %w", err)
+		}
+
+		findings, err := manager.Diagnose()
+		if err != nil {
+			return fmt.Errorf("failed to diagnose: %w", err)
+		}
+
+		// Repairs are reported on stderr when stdout carries data
+		report := os.Stdout
+		if !printer.Human() {
+			report = os.Stderr
+		}
+
+		if doctorFix {
+			fixed, err := manager.Fix(findings)
+			for _, finding := range fixed {
+				fmt.Fprintf(report, "✓ fixed %s: %s\n", finding.Code, finding.Message)
+			}
+			if err != nil {
+				fmt.Fprintf(report, "! some repairs failed: %v\n", err)
+			}
+
+			// Re-run the checks to report what is left
+			if len(fixed) > 0 {
+				if findings, err = manager.Diagnose(); err != nil {
+					return fmt.Errorf("failed to diagnose: %w", err)
+				}
+				if printer.Human() {
+					fmt.Println()
+				}
+			}
+		}
+
+		if findings == nil {
+			findings = []context.Finding{}
+		}
+		errorCount, warningCount := 0, 0
+		codes := make([]string, len(findings))
+		for i, finding := range findings {
+			switch finding.Severity {
+			case context.SeverityError:
+				errorCount++
+			case context.SeverityWarning:
+				warningCount++
+			}
+			codes[i] = finding.Code
+		}
+
+		err = printer.Print(findings, codes, func() {
+			printFindings(findings, errorCount, warningCount)
+		})
+		if err != nil {
+			return err
+		}
+
+		if errorCount > 0 {
+			return fmt.Errorf("doctor found %d error(s)", errorCount)
+		}
+		return nil
+	},
+}
+
+// printFindings prints the findings of doctor for people
+func printFindings(findings []context.Finding, errorCount, warningCount int) {
+	if len(findings) == 0 {
+		fmt.Println("✓ No problems found")
+		return
+	}
+
+	for _, finding := range findings {
+		fixable := ""
+		if finding.Fixable && !doctorFix {
+			fixable = " (fixable)"
+		}
+		fmt.Printf("%-8s %-18s %s%s\n", strings.ToUpper(string(finding.Severity)), finding.Code, finding.Message, fixable)
+		if finding.Path != "" {
+			fmt.Printf("%-8s %-18s %s\n", "", "", finding.Path)
+		}
+	}
+
+	fmt.Printf("\n%d error(s), %d warning(s)\n", errorCount, warningCount)
+	if !doctorFix && hasFixable(findings) {
+		printHint("Use 'cldenv doctor --fix' to repair fixable problems\n")
+	}
+}
+
+func init() {
+	doctorCmd.Flags().BoolVar(&doctorFix, "fix", false, "repair problems that can be fixed safely")
+}
+
+func hasFixable(findings []context.Finding) bool {
+	for _, finding := range findings {
+		if finding.Fixable {
+			return true
+		}
+	}
+	return false
+}
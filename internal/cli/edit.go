@@ -0,0 +1,167 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/1outres/cldenv/internal/config"
+	"github.com/1outres/cldenv/internal/context"
+	"github.com/1outres/cldenv/internal/schema"
+)
+
+var editForce bool
+
+// editCmd represents the edit command
+var editCmd = &cobra.Command{
+	Use:   "edit <context> [file]",
+	Short: "Edit a file of a context",
+	Long: `Open a file of a context in $VISUAL or $EDITOR (CLAUDE.md by default) and
+record the change in the context history.
+
+settings.json is edited in a copy that only replaces the file once it is valid
+JSON; use --force to save it regardless.`,
+	Args: cobra.RangeArgs(1, 2),
+	Annotations: map[string]string{
+		noOutputAnnotation: "true",
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		contextName := args[0]
+		file := config.ClaudeFile
+		if len(args) == 2 {
+			file = filepath.Clean(args[1])
+		}
+		if filepath.IsAbs(file) || file == ".." || strings.HasPrefix(file, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("file must be inside the context: %s", args[1])
+		}
+
+		manager, err := context.NewManager()
+		if err != nil {
+			return fmt.Errorf("failed to create context manager: %w", err)
+		}
+
+		if !manager.ContextExists(contextName) {
+			return fmt.Errorf("%w: '%s'", context.ErrContextNotFound, contextName)
+		}
+
+		path, err := config.GetContextFilePath(contextName, file)
+		if err != nil {
+			return fmt.Errorf("failed to get file path: %w", err)
+		}
+		if err := config.EnsureDir(path); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", file, err)
+		}
+
+		if file == config.SettingsFile {
+			err = editSettings(path)
+		} else {
+			err = runEditor(path)
+		}
+		if err != nil {
+			return err
+		}
+
+		manager.RecordEdit(contextName, file)
+
+		// Re-render a layered context in use, or resolve secrets newly
+		// referenced by its settings, so the edit takes effect
+		if manager.GetActiveContext() == contextName && (manager.IsLayered(contextName) || file == config.SettingsFile) {
+			if err := manager.SwitchContext(contextName); err != nil {
+				return fmt.Errorf("failed to refresh context '%s': %w", contextName, err)
+			}
+		}
+
+		return nil
+	},
+}
+
+// editSettings edits a copy of settings.json and only installs it if it is
+// valid, offering to edit it again otherwise
+func editSettings(path string) error {
+	original, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	// Keep the .json extension so editors recognize the file
+	tmp := filepath.Join(filepath.Dir(path), fmt.Sprintf("settings.tmp-%d.json", os.Getpid()))
+	if err := os.WriteFile(tmp, original, 0644); err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp)
+
+	for {
+		if err := runEditor(tmp); err != nil {
+			return err
+		}
+
+		edited, err := os.ReadFile(tmp)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", tmp, err)
+		}
+		if bytes.Equal(edited, original) {
+			return nil
+		}
+
+		issues := schema.ValidateSettings(edited)
+		for _, issue := range issues {
+			fmt.Fprintf(os.Stderr, "%s: %s: %s\n", severityLabel(issue.Severity), config.SettingsFile, issue)
+		}
+
+		if !schema.HasErrors(issues) || editForce {
+			if err := os.Rename(tmp, path); err != nil {
+				return fmt.Errorf("failed to save %s: %w", path, err)
+			}
+			return nil
+		}
+
+		if !confirm("Edit again?") {
+			return fmt.Errorf("%w, changes discarded", context.ErrInvalidSettings)
+		}
+	}
+}
+
+// confirm asks a yes/no question on the terminal, defaulting to yes
+func confirm(question string) bool {
+	fmt.Fprintf(os.Stderr, "%s [Y/n] ", question)
+
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && answer == "" {
+		fmt.Fprintln(os.Stderr)
+		return false
+	}
+
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "" || answer == "y" || answer == "yes"
+}
+
+// runEditor opens path in the user's editor and waits for it to exit
+func runEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	// Run through the shell so editors with arguments (e.g. "code --wait") work
+	cmd := exec.Command("sh", "-c", editor+` "$@"`, "sh", path)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("editor exited with error: %w", err)
+	}
+	return nil
+}
+
+func init() {
+	editCmd.Flags().BoolVar(&editForce, "force", false, "save settings.json even if it is not valid")
+}
@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/1outres/cldenv/internal/context"
+	"github.com/1outres/cldenv/pkg/envfile"
+)
+
+// envTrackingVar names the variable listing what cldenv exported in a shell,
+// so variables of the previous context can be removed
+const envTrackingVar = "CLDENV_ENV_VARS"
+
+var envShell string
+
+// envCmd represents the env command
+var envCmd = &cobra.Command{
+	Use:   "env [context]",
+	Short: "Print shell commands exporting the environment of a context",
+	Long: `Print shell commands exporting the variables in the env file of a context,
+the active one by default. Layered contexts inherit the variables of their
+parents. Variables exported for a previous context that the new one does not
+set are removed.
+
+The env file holds NAME=value lines; values may be quoted and are not expanded.
+Edit it with 'cldenv edit <context> env'.`,
+	Example: `  eval "$(cldenv env)"
+  eval "$(cldenv env work --shell zsh)"
+  cldenv env work --shell fish | source
+  cldenv env work --output json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		printer, err := newPrinter()
+		if err != nil {
+			return err
+		}
+		shell, err := resolveEnvShell(envShell)
+		if err != nil {
+			return err
+		}
+
+		manager, err := context.NewManager()
+		if err != nil {
+			return fmt.Errorf("failed to create context manager: %w", err)
+		}
+
+		contextName := manager.GetActiveContext()
+		if len(args) == 1 {
+			contextName = args[0]
+		}
+		if contextName == "" {
+			return fmt.Errorf("%w: no context is active", context.ErrContextNotFound)
+		}
+
+		vars, err := manager.Env(contextName)
+		if err != nil {
+			return fmt.Errorf("failed to read environment of '%s': %w", contextName, err)
+		}
+
+		names := make([]string, len(vars))
+		for i, v := range vars {
+			names[i] = v.Name
+		}
+		return printer.Print(vars, names, func() {
+			for _, line := range envCommands(shell, vars) {
+				fmt.Println(line)
+			}
+		})
+	},
+}
+
+// resolveEnvShell returns the shell to print commands for, by default the
+// login shell if it is supported
+func resolveEnvShell(shell string) (string, error) {
+	if shell == "" {
+		shell = filepath.Base(os.Getenv("SHELL"))
+		if !slices.Contains(envfile.Shells, shell) {
+			shell = "bash"
+		}
+	}
+	if !slices.Contains(envfile.Shells, shell) {
+		return "", usageError{fmt.Errorf("unsupported shell '%s' (supported: %s)", shell, strings.Join(envfile.Shells, ", "))}
+	}
+	return shell, nil
+}
+
+// envCommands returns the commands that replace the variables exported for
+// the previous context with vars
+func envCommands(shell string, vars []envfile.Var) []string {
+	previous := strings.Fields(os.Getenv(envTrackingVar))
+	var lines, names []string
+	for _, v := range vars {
+		names = append(names, v.Name)
+	}
+
+	for _, name := range previous {
+		if !slices.Contains(names, name) {
+			lines = append(lines, envfile.Unset(shell, name))
+		}
+	}
+	for _, v := range vars {
+		lines = append(lines, envfile.Export(shell, v))
+	}
+
+	switch {
+	case len(names) > 0:
+		lines = append(lines, envfile.Export(shell, envfile.Var{Name: envTrackingVar, Value: strings.Join(names, " ")}))
+	case len(previous) > 0:
+		lines = append(lines, envfile.Unset(shell, envTrackingVar))
+	}
+	return lines
+}
+
+func init() {
+	envCmd.Flags().StringVar(&envShell, "shell", "", "shell to print commands for: bash, zsh or fish (default: $SHELL)")
+}
@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"github.com/1outres/cldenv/internal/context"
+	"golang.org/x/term"
+)
+
+var execForce bool
+
+// execCmd represents the exec command
+var execCmd = &cobra.Command{
+	Use:   "exec <context> -- <command> [args...]",
+	Short: "Run a command under a context without switching",
+	Long: `Run a command, usually claude, under a context without switching globally.
+The context is materialized into a private configuration directory in
+~/.cldenv/.exec and the command is started with CLAUDE_CONFIG_DIR pointing
+there, so terminals can use different contexts at the same time. Credentials
+and session history are shared with ~/.claude, or in isolated mode with the
+context's own directory. The directory is removed when the command exits;
+artifacts such as commands/ that the command created are moved to the
+context, other new files to the shared directory.
+
+The variables in the env file of the context are set, and CLDENV_CONTEXT is
+set to the name of the context. cldenv exits with the exit status of the
+command and forwards termination signals to it.`,
+	Example: `  cldenv exec work -- claude
+  cldenv exec client-a -- claude -p "summarize the open PRs"`,
+	Args: cobra.MinimumNArgs(2),
+	Annotations: map[string]string{
+		noOutputAnnotation: "true",
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		contextName, command := args[0], args[1:]
+		// Flags stop at the context, so a "--" after it is passed through
+		if command[0] == "--" {
+			command = command[1:]
+		}
+		if len(command) == 0 {
+			return usageError{fmt.Errorf("no command given")}
+		}
+
+		return runUnderContext(contextName, command, execForce)
+	},
+}
+
+// runUnderContext runs a command with a private configuration directory and
+// the environment of a context, and returns its exit status
+func runUnderContext(contextName string, command []string, force bool) error {
+	manager, err := context.NewManager()
+	if err != nil {
+		return fmt.Errorf("failed to create context manager: %w", err)
+	}
+
+	issues, err := manager.ValidateContext(contextName)
+	if err != nil {
+		return fmt.Errorf("failed to validate context '%s': %w", contextName, err)
+	}
+	if err := checkValidation(issues, force); err != nil {
+		return err
+	}
+
+	vars, err := manager.Env(contextName)
+	if err != nil {
+		return fmt.Errorf("failed to read environment of '%s': %w", contextName, err)
+	}
+
+	dir, cleanup, err := manager.PrepareConfigDir(contextName)
+	if err != nil {
+		return fmt.Errorf("failed to prepare context '%s': %w", contextName, err)
+	}
+	defer cleanup()
+
+	child := exec.Command(command[0], command[1:]...)
+	child.Stdin, child.Stdout, child.Stderr = os.Stdin, os.Stdout, os.Stderr
+	child.Env = os.Environ()
+	for _, v := range vars {
+		child.Env = append(child.Env, v.Name+"="+v.Value)
+	}
+	child.Env = append(child.Env, "CLAUDE_CONFIG_DIR="+dir, "CLDENV_CONTEXT="+contextName)
+
+	// Keep running until the child exits so the directory is cleaned up
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	defer func() {
+		signal.Stop(signals)
+		close(signals)
+	}()
+
+	if err := child.Start(); err != nil {
+		return fmt.Errorf("failed to start %s: %w", command[0], err)
+	}
+	// A terminal delivers Ctrl-C to the whole process group, so forwarding it
+	// too would interrupt the command twice
+	fromTerminal := term.IsTerminal(int(os.Stdin.Fd()))
+	go func() {
+		for sig := range signals {
+			if sig != os.Interrupt || !fromTerminal {
+				child.Process.Signal(sig)
+			}
+		}
+	}()
+
+	if err := child.Wait(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return err
+		}
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			return exitStatus(128 + int(status.Signal()))
+		}
+		return exitStatus(exitErr.ExitCode())
+	}
+	return nil
+}
+
+func init() {
+	// Flags after the context belong to the command, even without "--"
+	execCmd.Flags().SetInterspersed(false)
+	execCmd.Flags().BoolVar(&execForce, "force", false, "run even if settings.json is invalid")
+}
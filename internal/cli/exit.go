@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/1outres/cldenv/internal/context"
+	"github.com/1outres/cldenv/internal/history"
+	"github.com/1outres/cldenv/pkg/filelock"
+)
+
+// Exit codes of cldenv. Scripts rely on them, so existing values must not change.
+const (
+	exitOK               = 0
+	exitError            = 1 // any failure not listed below
+	exitUsage            = 2 // unknown command, flag or wrong number of arguments
+	exitNotFound         = 3 // context, revision, template, previous context or credentials do not exist
+	exitAlreadyExists    = 4 // context already exists
+	exitInvalidName      = 5 // context name is not allowed
+	exitValidationFailed = 6 // settings.json, an env file or an archive is invalid
+	exitLockBusy         = 7 // another cldenv process holds the lock
+	exitIOError          = 8 // reading or writing a file failed
+)
+
+// usageError is an error in how cldenv was invoked rather than in what it did
+type usageError struct {
+	err error
+}
+
+func (e usageError) Error() string { return e.err.Error() }
+func (e usageError) Unwrap() error { return e.err }
+
+// exitStatus is returned by commands that exit with the status of a child
+// process. It is not reported as an error.
+type exitStatus int
+
+func (s exitStatus) Error() string { return fmt.Sprintf("exit status %d", int(s)) }
+
+// exitCode maps an error returned by a command to the exit code of the process
+func exitCode(err error) int {
+	var usage usageError
+	var status exitStatus
+	var pathErr *fs.PathError
+	var linkErr *os.LinkError
+	var syscallErr *os.SyscallError
+
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &status):
+		return int(status)
+	case errors.As(err, &usage):
+		return exitUsage
+	case errors.Is(err, context.ErrContextNotFound),
+		errors.Is(err, context.ErrTemplateNotFound),
+		errors.Is(err, context.ErrNoPreviousContext),
+		errors.Is(err, context.ErrNoCredentials),
+		errors.Is(err, context.ErrSecretNotFound),
+		errors.Is(err, history.ErrRevisionNotFound):
+		return exitNotFound
+	case errors.Is(err, context.ErrContextAlreadyExists):
+		return exitAlreadyExists
+	case errors.Is(err, context.ErrInvalidContextName), errors.Is(err, context.ErrInvalidSecretName):
+		return exitInvalidName
+	case errors.Is(err, context.ErrInvalidSettings), errors.Is(err, context.ErrInvalidArchive),
+		errors.Is(err, context.ErrInvalidEnv):
+		return exitValidationFailed
+	case errors.Is(err, filelock.ErrLocked):
+		return exitLockBusy
+	case errors.As(err, &pathErr), errors.As(err, &linkErr), errors.As(err, &syscallErr):
+		return exitIOError
+	default:
+		return exitError
+	}
+}
+
+// markUsageErrors makes argument errors of cmd and its subcommands exit with exitUsage
+func markUsageErrors(cmd *cobra.Command) {
+	if validate := cmd.Args; validate != nil {
+		cmd.Args = func(cmd *cobra.Command, args []string) error {
+			if err := validate(cmd, args); err != nil {
+				return usageError{err}
+			}
+			return nil
+		}
+	}
+
+	for _, sub := range cmd.Commands() {
+		markUsageErrors(sub)
+	}
+}
+
+// printSuccess reports a completed change on stdout, unless --quiet is given or
+// the result is printed in another format with printResult
+func printSuccess(format string, a ...any) {
+	if !quiet && humanOutput() {
+		fmt.Printf(format, a...)
+	}
+}
+
+// printHint suggests what to do next on stderr, unless --quiet is given. Hints
+// never go to stdout, so scripts only see results there.
+func printHint(format string, a ...any) {
+	if !quiet {
+		fmt.Fprintf(os.Stderr, format, a...)
+	}
+}
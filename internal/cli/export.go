@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/1outres/cldenv/internal/context"
+)
+
+var exportFile string
+
+// exportCmd represents the export command
+var exportCmd = &cobra.Command{
+	Use:   "export <context...> -f <file.tar.gz>",
+	Short: "Export contexts to a portable archive",
+	Long: `Export one or more contexts to a gzipped tar archive that can be installed
+elsewhere with 'cldenv import'. The archive carries a manifest with the file list
+and checksums. Use '-f -' to write the archive to standard output.
+
+Parents of layered contexts are not included unless listed.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		manager, err := context.NewManager()
+		if err != nil {
+			return fmt.Errorf("failed to create context manager: %w", err)
+		}
+
+		for _, name := range args {
+			if !manager.ContextExists(name) {
+				return fmt.Errorf("%w: '%s'", context.ErrContextNotFound, name)
+			}
+		}
+
+		if exportFile == "-" {
+			// The archive is the output
+			if !humanOutput() {
+				return usageError{fmt.Errorf("--output and --format-template cannot be used with '-f -'")}
+			}
+			return manager.Export(os.Stdout, args, version)
+		}
+
+		tmp := exportFile + ".tmp"
+		file, err := os.Create(tmp)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", exportFile, err)
+		}
+		if err := writeExport(manager, file, args); err != nil {
+			os.Remove(tmp)
+			return err
+		}
+		if err := os.Rename(tmp, exportFile); err != nil {
+			os.Remove(tmp)
+			return fmt.Errorf("failed to write %s: %w", exportFile, err)
+		}
+
+		printSuccess("✓ Exported %d context(s) to %s\n", len(args), exportFile)
+		return printResult(exportResult{Contexts: args, File: exportFile}, args)
+	},
+}
+
+// exportResult is the machine-readable output of the export command
+type exportResult struct {
+	Contexts []string `json:"contexts"`
+	File     string   `json:"file"`
+}
+
+// writeExport writes the archive and closes the file
+func writeExport(manager *context.Manager, file io.WriteCloser, names []string) error {
+	if err := manager.Export(file, names, version); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to export: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to export: %w", err)
+	}
+	return nil
+}
+
+func init() {
+	exportCmd.Flags().StringVarP(&exportFile, "file", "f", "", "archive to write, or - for standard output")
+	exportCmd.MarkFlagRequired("file")
+}
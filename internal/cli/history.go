@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/1outres/cldenv/internal/context"
+	"github.com/1outres/cldenv/internal/history"
+	"github.com/1outres/cldenv/internal/output"
+)
+
+var historySwitches bool
+
+// historyCmd represents the history command
+var historyCmd = &cobra.Command{
+	Use:   "history <context> | history --switches [context]",
+	Short: "Show the recorded revisions of a context",
+	Long: `Show the recorded revisions of a context, newest first.
+Every create, remove, edit, adopt and rollback is recorded in a git repository
+in ~/.cldenv, or in a built-in snapshot store if git is not installed.
+
+With --switches, show the recent switches between contexts on this machine
+instead, optionally only those to a given context.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if historySwitches {
+			return cobra.MaximumNArgs(1)(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		printer, err := newPrinter()
+		if err != nil {
+			return err
+		}
+
+		manager, err := context.NewManager()
+		if err != nil {
+			return fmt.Errorf("failed to create context manager: %w", err)
+		}
+
+		if historySwitches {
+			return printSwitches(printer, manager, args)
+		}
+		contextName := args[0]
+
+		revisions, err := manager.History(contextName)
+		if err != nil {
+			return fmt.Errorf("failed to read history of '%s': %w", contextName, err)
+		}
+
+		if revisions == nil {
+			revisions = []history.Revision{}
+		}
+		ids := make([]string, len(revisions))
+		for i, revision := range revisions {
+			ids[i] = revision.ID
+		}
+
+		return printer.Print(revisions, ids, func() {
+			if len(revisions) == 0 {
+				fmt.Printf("No history recorded for context '%s'.\n", contextName)
+				return
+			}
+			for _, revision := range revisions {
+				fmt.Printf("%s  %s  %s\n", revision.ID, revision.Time.Format("2006-01-02 15:04:05"), revision.Message)
+			}
+		})
+	},
+}
+
+// printSwitches prints the switch log, only switches to args[0] if given
+func printSwitches(printer *output.Printer, manager *context.Manager, args []string) error {
+	switches, err := manager.Switches()
+	if err != nil {
+		return err
+	}
+
+	filtered := []context.Switch{}
+	for _, s := range switches {
+		if len(args) == 0 || s.To == args[0] {
+			filtered = append(filtered, s)
+		}
+	}
+	names := make([]string, len(filtered))
+	for i, s := range filtered {
+		names[i] = s.To
+	}
+
+	return printer.Print(filtered, names, func() {
+		if len(filtered) == 0 {
+			fmt.Println("No switches recorded.")
+			return
+		}
+		for _, s := range filtered {
+			fmt.Printf("%s  %s -> %s\n", s.Time.Local().Format("2006-01-02 15:04:05"), valueOr(s.From, "(none)"), s.To)
+		}
+	})
+}
+
+func init() {
+	historyCmd.Flags().BoolVar(&historySwitches, "switches", false, "show recent switches between contexts instead")
+}
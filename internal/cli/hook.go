@@ -0,0 +1,141 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/1outres/cldenv/internal/context"
+	"github.com/spf13/cobra"
+)
+
+const bashHook = `_cldenv_hook() {
+  local previous_exit_status=$?
+  if [[ "${_CLDENV_LAST_PWD:-}" != "$PWD" ]]; then
+    _CLDENV_LAST_PWD="$PWD"
+    eval "$(%[1]s auto-switch --env bash)"
+  fi
+  return $previous_exit_status
+}
+if [[ ";${PROMPT_COMMAND[*]:-};" != *";_cldenv_hook;"* ]]; then
+  PROMPT_COMMAND="_cldenv_hook${PROMPT_COMMAND:+;$PROMPT_COMMAND}"
+fi
+`
+
+const zshHook = `_cldenv_hook() {
+  eval "$(%[1]s auto-switch --env zsh)"
+}
+typeset -ag chpwd_functions
+if (( ! ${chpwd_functions[(I)_cldenv_hook]} )); then
+  chpwd_functions=(_cldenv_hook $chpwd_functions)
+fi
+_cldenv_hook
+`
+
+const fishHook = `function _cldenv_hook --on-variable PWD
+    %[1]s auto-switch --env fish | source
+end
+_cldenv_hook
+`
+
+var shellHooks = map[string]string{
+	"bash": bashHook,
+	"zsh":  zshHook,
+	"fish": fishHook,
+}
+
+// hookCmd represents the hook command
+var hookCmd = &cobra.Command{
+	Use:   "hook <bash|zsh|fish>",
+	Short: "Print a shell hook that switches context on directory change",
+	Long: `Print a shell snippet that switches to the context requested by a
+.cldenv-context file whenever the current directory changes, and exports the
+variables in the env file of the active context (see 'cldenv env').
+
+Add one of the following to your shell configuration:
+
+  eval "$(cldenv hook bash)"     # ~/.bashrc
+  eval "$(cldenv hook zsh)"      # ~/.zshrc
+  cldenv hook fish | source      # ~/.config/fish/config.fish`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: []string{"bash", "zsh", "fish"},
+	Annotations: map[string]string{
+		noOutputAnnotation: "true",
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		hook, ok := shellHooks[args[0]]
+		if !ok {
+			return fmt.Errorf("unsupported shell '%s' (supported: bash, zsh, fish)", args[0])
+		}
+
+		executable, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("failed to locate cldenv executable: %w", err)
+		}
+
+		fmt.Printf(hook, shellQuote(executable))
+		return nil
+	},
+}
+
+var autoSwitchEnv string
+
+// autoSwitchCmd is invoked by the shell hook on every directory change
+var autoSwitchCmd = &cobra.Command{
+	Use:    "auto-switch",
+	Short:  "Switch to the context requested by the current directory",
+	Hidden: true,
+	Args:   cobra.NoArgs,
+	// Runs on every directory change, so it skips the startup work and only
+	// reports anything when a switch fails
+	Annotations: map[string]string{
+		skipInitAnnotation:         "true",
+		skipDriftWarningAnnotation: "true",
+		noOutputAnnotation:         "true",
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return nil
+		}
+
+		manager, err := context.NewManager()
+		if err != nil {
+			return nil
+		}
+
+		switched, err := manager.AutoSwitch(cwd)
+		if err != nil {
+			return fmt.Errorf("failed to switch context: %w", err)
+		}
+
+		// Shells started by exec or shell keep the environment of their context
+		if autoSwitchEnv == "" || os.Getenv("CLDENV_CONTEXT") != "" {
+			return nil
+		}
+		active := manager.GetActiveContext()
+		if active == "" {
+			return nil
+		}
+		vars, err := manager.Env(active)
+		if err != nil {
+			if switched == "" {
+				return nil
+			}
+			return fmt.Errorf("failed to read environment of '%s': %w", active, err)
+		}
+		for _, line := range envCommands(autoSwitchEnv, vars) {
+			fmt.Println(line)
+		}
+		return nil
+	},
+}
+
+func init() {
+	autoSwitchCmd.Flags().StringVar(&autoSwitchEnv, "env", "", "print commands exporting the environment of the active context for this shell")
+}
+
+// shellQuote quotes a string for use in POSIX shells and fish
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
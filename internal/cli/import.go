@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/1outres/cldenv/internal/context"
+)
+
+var (
+	importAs        string
+	importOverwrite bool
+	importDryRun    bool
+	importForce     bool
+)
+
+// importCmd represents the import command
+var importCmd = &cobra.Command{
+	Use:   "import <file.tar.gz>",
+	Short: "Import contexts from an archive",
+	Long: `Import the contexts of an archive created by 'cldenv export'. Use '-' to read
+the archive from standard input.
+
+The archive is verified against its manifest and the contexts it would install
+are listed before anything is written. Existing contexts are only replaced with
+--overwrite, and contexts with invalid settings.json are only imported with --force.
+
+--as imports a context under another name; contexts in the archive that extend
+it are pointed at the new name.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		manager, err := context.NewManager()
+		if err != nil {
+			return fmt.Errorf("failed to create context manager: %w", err)
+		}
+
+		var archive *context.Archive
+		if args[0] == "-" {
+			archive, err = context.ReadArchive(os.Stdin)
+		} else {
+			archive, err = context.ReadArchiveFile(args[0])
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read archive: %w", err)
+		}
+
+		items, err := manager.PlanImport(archive, importAs)
+		if err != nil {
+			return err
+		}
+
+		conflicts := 0
+		for _, item := range items {
+			if item.Exists {
+				conflicts++
+			}
+		}
+
+		// The preview is the result of --dry-run, otherwise it is informational
+		if humanOutput() && (importDryRun || !quiet) {
+			printImportPreview(archive, items)
+		}
+
+		if err := checkValidation(archive.Validate(), importForce); err != nil {
+			return err
+		}
+
+		if conflicts > 0 && !importOverwrite {
+			return fmt.Errorf("%w: %d context(s), use --overwrite to replace them", context.ErrContextAlreadyExists, conflicts)
+		}
+		names := make([]string, len(items))
+		for i, item := range items {
+			names[i] = item.Name
+		}
+		if importDryRun {
+			return printResult(items, names)
+		}
+
+		if _, err := manager.Import(archive, importAs, importOverwrite); err != nil {
+			return fmt.Errorf("failed to import: %w", err)
+		}
+
+		for _, item := range items {
+			printSuccess("✓ Imported context '%s'\n", item.Name)
+		}
+		return printResult(items, names)
+	},
+}
+
+// printImportPreview lists the contexts an archive would install
+func printImportPreview(archive *context.Archive, items []context.ImportItem) {
+	fmt.Printf("Archive created by cldenv %s on %s:\n", archive.Manifest.Version, archive.Manifest.Created.Local().Format("2006-01-02 15:04"))
+	for _, item := range items {
+		marker, note := "+", "new"
+		if item.Exists {
+			marker, note = "~", "replaces existing context"
+		}
+		name := item.Name
+		if item.Source != item.Name {
+			name = fmt.Sprintf("%s (from %s)", item.Name, item.Source)
+		}
+		fmt.Printf("  %s %s: %d file(s), %s\n", marker, name, item.Files, note)
+		if item.Extends != "" {
+			fmt.Printf("      extends '%s'\n", item.Extends)
+		}
+	}
+}
+
+func init() {
+	importCmd.Flags().StringVar(&importAs, "as", "", "import the single context of the archive under this name, or one of several with <context>=<name>")
+	importCmd.Flags().BoolVar(&importOverwrite, "overwrite", false, "replace contexts that already exist")
+	importCmd.Flags().BoolVar(&importDryRun, "dry-run", false, "only list what would be imported")
+	importCmd.Flags().BoolVar(&importForce, "force", false, "import even if settings.json is invalid")
+}
@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/1outres/cldenv/internal/context"
+)
+
+var localUnset bool
+
+// localCmd represents the local command
+var localCmd = &cobra.Command{
+	Use:   "local [context]",
+	Short: "Pin a context for the current directory",
+	Long: `Pin a context for the current directory by writing a .cldenv-context file.
+cldenv looks for this file in the current directory and its parents to find
+the context a project requests. Without arguments, the requested context is shown.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+
+		if localUnset {
+			if err := context.RemoveLocalContext(cwd); err != nil {
+				return err
+			}
+			printSuccess("✓ Removed directory context pin\n")
+			if humanOutput() {
+				return nil
+			}
+			// A pin in a parent directory may still apply
+			return printLocalContext(cwd)
+		}
+
+		if len(args) == 0 {
+			return printLocalContext(cwd)
+		}
+
+		contextName := args[0]
+
+		manager, err := context.NewManager()
+		if err != nil {
+			return fmt.Errorf("failed to create context manager: %w", err)
+		}
+
+		if !manager.ContextExists(contextName) {
+			return fmt.Errorf("%w: '%s'", context.ErrContextNotFound, contextName)
+		}
+
+		file, err := context.WriteLocalContext(cwd, contextName)
+		if err != nil {
+			return fmt.Errorf("failed to pin context '%s': %w", contextName, err)
+		}
+
+		printSuccess("✓ Pinned context '%s' in %s\n", contextName, file)
+		if manager.GetActiveContext() != contextName {
+			printHint("Use 'cldenv use %s' to switch to this context\n", contextName)
+		}
+		return printResult(context.LocalContext{Name: contextName, File: file}, []string{contextName})
+	},
+}
+
+// printLocalContext prints the context requested by a directory, or null in
+// the json and yaml formats if there is none
+func printLocalContext(dir string) error {
+	printer, err := newPrinter()
+	if err != nil {
+		return err
+	}
+
+	local, err := context.FindLocalContext(dir)
+	if err != nil {
+		return fmt.Errorf("failed to resolve directory context: %w", err)
+	}
+
+	var names []string
+	if local != nil {
+		names = append(names, local.Name)
+	}
+	return printer.Print(local, names, func() {
+		if local == nil {
+			fmt.Println("No directory context set.")
+			printHint("Use 'cldenv local <context>' to pin a context for this directory\n")
+			return
+		}
+		fmt.Printf("%s (set by %s)\n", local.Name, local.File)
+	})
+}
+
+func init() {
+	localCmd.Flags().BoolVar(&localUnset, "unset", false, "remove the .cldenv-context file in the current directory")
+}
@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/1outres/cldenv/internal/config"
+	"github.com/1outres/cldenv/internal/context"
+)
+
+const (
+	modeLinks    = "links"
+	modeIsolated = "isolated"
+)
+
+// modeCmd represents the mode command
+var modeCmd = &cobra.Command{
+	Use:   "mode [links|isolated]",
+	Short: "Show or change how contexts are applied to ~/.claude",
+	Long: `Show or change how contexts are applied to ~/.claude.
+
+In links mode, the default, contexts only provide the managed files such as
+CLAUDE.md and settings.json, which are linked into a shared ~/.claude.
+
+In isolated mode every context owns a whole Claude configuration directory in
+~/.cldenv/.homes/<context>, including credentials, session history, todos and
+plugins, and ~/.claude is a link that 'cldenv use' points at the directory of
+the active context. Switching to isolated mode moves the current ~/.claude into
+the directory of the active context; other contexts start empty.
+
+Switching back to links mode moves the directory of the active context back to
+~/.claude. The directories of other contexts are kept.`,
+	Example: `  cldenv mode
+  cldenv mode isolated
+  cldenv mode links`,
+	Args:      cobra.MaximumNArgs(1),
+	ValidArgs: []string{modeLinks, modeIsolated},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			printer, err := newPrinter()
+			if err != nil {
+				return err
+			}
+			mode := modeLinks
+			if config.IsIsolated() {
+				mode = modeIsolated
+			}
+			return printer.Print(modeResult{Mode: mode}, []string{mode}, func() {
+				fmt.Println(mode)
+			})
+		}
+
+		manager, err := context.NewManager()
+		if err != nil {
+			return fmt.Errorf("failed to create context manager: %w", err)
+		}
+
+		switch args[0] {
+		case modeIsolated:
+			if err := manager.Isolate(); err != nil {
+				return fmt.Errorf("failed to switch to isolated mode: %w", err)
+			}
+			printSuccess("✓ Every context now has its own Claude directory; '%s' keeps the current one\n", manager.GetActiveContext())
+			return printResult(modeResult{Mode: modeIsolated}, []string{modeIsolated})
+		case modeLinks:
+			kept, err := manager.Unisolate()
+			if err != nil {
+				return fmt.Errorf("failed to switch to links mode: %w", err)
+			}
+			printSuccess("✓ Contexts now share ~/.claude\n")
+			for _, dir := range kept {
+				printHint("Kept %s\n", dir)
+			}
+			return printResult(modeResult{Mode: modeLinks, Kept: kept}, []string{modeLinks})
+		default:
+			return usageError{fmt.Errorf("unknown mode '%s', expected %s or %s", args[0], modeLinks, modeIsolated)}
+		}
+	},
+}
+
+// modeResult is the machine-readable output of the mode command
+type modeResult struct {
+	Mode string `json:"mode"`
+	// Kept are the directories of other contexts left behind when leaving isolated mode
+	Kept []string `json:"kept,omitempty"`
+}
@@ -28,20 +28,8 @@ You cannot remove the default context or the currently active context.`,
 
 		// Check if context exists
 		if !manager.ContextExists(contextName) {
-			fmt.Printf("Context '%s' not found.\n\n", contextName)
-			
-			// Show available contexts
-			contexts := manager.GetContexts()
-			if len(contexts) > 0 {
-				fmt.Println("Available contexts:")
-				for _, ctx := range contexts {
-					fmt.Printf("  %s\n", ctx.Name)
-				}
-			} else {
-				fmt.Println("No contexts available.")
-			}
-			
-			return nil
+			printAvailableContexts(manager)
+			return fmt.Errorf("%w: '%s'", context.ErrContextNotFound, contextName)
 		}
 
 		// Remove the context
@@ -49,7 +37,12 @@ You cannot remove the default context or the currently active context.`,
 			return fmt.Errorf("failed to remove context '%s': %w", contextName, err)
 		}
 
-		fmt.Printf("✓ Removed context '%s'\n", contextName)
-		return nil
+		printSuccess("✓ Removed context '%s'\n", contextName)
+		return printResult(removeResult{Removed: contextName}, []string{contextName})
 	},
+}
+
+// removeResult is the machine-readable output of the remove command
+type removeResult struct {
+	Removed string `json:"removed"`
 }
\ No newline at end of file
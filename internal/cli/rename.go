@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/1outres/cldenv/internal/context"
+)
+
+// renameCmd represents the rename command
+var renameCmd = &cobra.Command{
+	Use:   "rename <old> <new>",
+	Short: "Rename a context",
+	Long: `Rename a context. Contexts that extend it and .cldenv-context files written by
+'cldenv local' are updated to the new name. Renaming the active context
+re-points the links in ~/.claude in a single step.
+
+The default context cannot be renamed until another context is made the
+default with 'cldenv set-default'.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		oldName, newName := args[0], args[1]
+
+		manager, err := context.NewManager()
+		if err != nil {
+			return fmt.Errorf("failed to create context manager: %w", err)
+		}
+
+		result, err := manager.RenameContext(oldName, newName)
+		if err != nil {
+			return fmt.Errorf("failed to rename context '%s': %w", oldName, err)
+		}
+
+		printSuccess("✓ Renamed context '%s' to '%s'\n", oldName, newName)
+		for _, child := range result.Children {
+			printSuccess("  '%s' now extends '%s'\n", child, newName)
+		}
+		for _, pin := range result.Pins {
+			printSuccess("  updated %s\n", pin)
+		}
+		if result.Relinked {
+			printSuccess("  links in ~/.claude re-pointed\n")
+		}
+		return printResult(renameResult{From: oldName, To: newName, RenameResult: result}, []string{newName})
+	},
+}
+
+// renameResult is the machine-readable output of the rename command
+type renameResult struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	*context.RenameResult
+}
@@ -0,0 +1,38 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/1outres/cldenv/internal/context"
+)
+
+// rollbackCmd represents the rollback command
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback <context> <rev>",
+	Short: "Restore a context to a previous revision",
+	Long: `Restore the files of a context to a revision listed by 'cldenv history <context>'.
+The current state is recorded first, so a rollback can itself be undone.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		contextName, rev := args[0], args[1]
+
+		manager, err := context.NewManager()
+		if err != nil {
+			return fmt.Errorf("failed to create context manager: %w", err)
+		}
+
+		if err := manager.Rollback(contextName, rev); err != nil {
+			return fmt.Errorf("failed to roll back context '%s': %w", contextName, err)
+		}
+
+		printSuccess("✓ Rolled back context '%s' to %s\n", contextName, rev)
+		return printResult(rollbackResult{Context: contextName, Revision: rev}, []string{contextName})
+	},
+}
+
+// rollbackResult is the machine-readable output of the rollback command
+type rollbackResult struct {
+	Context  string `json:"context"`
+	Revision string `json:"revision"`
+}
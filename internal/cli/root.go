@@ -1,15 +1,23 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"slices"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/1outres/cldenv/internal/context"
+	"github.com/1outres/cldenv/internal/output"
 )
 
 var (
-	version = "dev"
+	version        = "dev"
+	listTag        string
+	outputFormat   string
+	outputTemplate string
+	quiet          bool
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -20,26 +28,103 @@ var rootCmd = &cobra.Command{
 between different ~/.claude/CLAUDE.md and ~/.claude/settings.json configurations 
 using symbolic links.`,
 	Version: version,
+	Args:    cobra.NoArgs,
+	// Execute reports errors itself, with a hint instead of the full usage
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// Check --output before changing anything, so a typo never goes unnoticed
+		if _, err := newPrinter(); err != nil {
+			return usageError{err}
+		}
+		if cmd.Annotations[noOutputAnnotation] == "true" && !humanOutput() {
+			return usageError{fmt.Errorf("'%s' has no result to print with --output or --format-template", cmd.CommandPath())}
+		}
+
+		if cmd.Annotations[skipInitAnnotation] != "true" {
+			initConfig()
+		}
+		if cmd.Annotations[skipDriftWarningAnnotation] != "true" {
+			warnDrift()
+		}
+		return nil
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return listContexts()
 	},
 }
 
+// skipInitAnnotation marks commands that must see the state as is, without
+// the migration and repairs done by initConfig
+const skipInitAnnotation = "cldenv/skip-init"
+
+// skipDriftWarningAnnotation marks commands that report drift themselves
+const skipDriftWarningAnnotation = "cldenv/skip-drift-warning"
+
+// noOutputAnnotation marks commands that only act, such as running an editor,
+// and have no result to print in another format
+const noOutputAnnotation = "cldenv/no-output"
+
 // Execute adds all child commands to the root command and sets flags appropriately.
+// The exit status tells scripts what went wrong, see exitCode.
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	cmd, err := rootCmd.ExecuteC()
+	if err != nil {
+		var status exitStatus
+		if errors.As(err, &status) {
+			os.Exit(int(status))
+		}
+
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		if exitCode(err) == exitUsage {
+			printHint("Run '%s --help' for usage.\n", cmd.CommandPath())
+		}
+		os.Exit(exitCode(err))
 	}
 }
 
 func init() {
-	cobra.OnInitialize(initConfig)
+	rootCmd.PersistentFlags().DurationVar(&context.LockTimeout, "lock-timeout", context.LockTimeout, "how long to wait for another cldenv process to finish")
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "only print results and errors, no confirmations or hints")
+	rootCmd.PersistentFlags().StringVar(&outputFormat, "output", string(output.Table), "output format: table, json, yaml or name")
+	rootCmd.PersistentFlags().StringVar(&outputTemplate, "format-template", "", "format the output with a Go template, applied to each item of a list")
+	rootCmd.Flags().StringVar(&listTag, "tag", "", "only list contexts with this tag")
 	
 	// Add subcommands
 	rootCmd.AddCommand(useCmd)
 	rootCmd.AddCommand(createCmd)
 	rootCmd.AddCommand(removeCmd)
+	rootCmd.AddCommand(localCmd)
+	rootCmd.AddCommand(currentCmd)
+	rootCmd.AddCommand(hookCmd)
+	rootCmd.AddCommand(autoSwitchCmd)
+	rootCmd.AddCommand(showCmd)
+	rootCmd.AddCommand(doctorCmd)
+	rootCmd.AddCommand(adoptCmd)
+	rootCmd.AddCommand(watchCmd)
+	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(diffCmd)
+	rootCmd.AddCommand(rollbackCmd)
+	rootCmd.AddCommand(editCmd)
+	rootCmd.AddCommand(syncCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(importCmd)
+	rootCmd.AddCommand(validateCmd)
+	rootCmd.AddCommand(renameCmd)
+	rootCmd.AddCommand(copyCmd)
+	rootCmd.AddCommand(setDefaultCmd)
+	rootCmd.AddCommand(describeCmd)
+	rootCmd.AddCommand(execCmd)
+	rootCmd.AddCommand(modeCmd)
+	rootCmd.AddCommand(credentialsCmd)
+	rootCmd.AddCommand(envCmd)
+	rootCmd.AddCommand(shellCmd)
+	rootCmd.AddCommand(secretCmd)
+
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return usageError{err}
+	})
+	markUsageErrors(rootCmd)
 }
 
 // initConfig reads in config file and ENV variables if set.
@@ -55,10 +140,65 @@ func initConfig() {
 	if err := context.EnsureDefaultContext(); err != nil {
 		fmt.Fprintf(os.Stderr, "Warning: Failed to ensure default context: %v\n", err)
 	}
+
+	if err := context.MigrateLastUsed(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Failed to migrate last use of contexts: %v\n", err)
+	}
+}
+
+// warnDrift points out edits that replaced a link while a context was active
+func warnDrift() {
+	if manager, err := context.NewManager(); err == nil {
+		for _, drift := range manager.DetectDrift() {
+			fmt.Fprintf(os.Stderr, "Warning: %s is no longer linked to context '%s'; run 'cldenv adopt'\n", drift.ClaudePath, drift.Context)
+		}
+	}
+}
+
+// newPrinter returns a printer for the format selected with --output or --format-template
+func newPrinter() (*output.Printer, error) {
+	return output.NewPrinter(os.Stdout, outputFormat, outputTemplate)
+}
+
+// humanOutput reports whether results are printed for people, i.e. neither
+// --output nor --format-template selects another format
+func humanOutput() bool {
+	return outputFormat == string(output.Table) && outputTemplate == ""
+}
+
+// printResult prints the result of a command that changes contexts in the
+// format selected with --output or --format-template. People get the
+// confirmations of printSuccess instead, so nothing is printed for table.
+func printResult(value any, names []string) error {
+	printer, err := newPrinter()
+	if err != nil {
+		return err
+	}
+	return printer.Print(value, names, func() {})
+}
+
+// printContextResult prints a context a command created or switched to with printResult
+func printContextResult(manager *context.Manager, name string) error {
+	if humanOutput() {
+		return nil
+	}
+	if err := manager.LoadContexts(); err != nil {
+		return fmt.Errorf("failed to load contexts: %w", err)
+	}
+	ctx, err := findContext(manager, name)
+	if err != nil {
+		return err
+	}
+	return printResult(ctx, []string{ctx.Name})
 }
 
 // listContexts lists all available contexts
 func listContexts() error {
+	printer, err := newPrinter()
+	if err != nil {
+		return err
+	}
+
 	manager, err := context.NewManager()
 	if err != nil {
 		return fmt.Errorf("failed to create context manager: %w", err)
@@ -71,31 +211,126 @@ func listContexts() error {
 	contexts := manager.GetContexts()
 	activeContext := manager.GetActiveContext()
 
-	if len(contexts) == 0 {
+	local, err := findLocalContext()
+	if err != nil {
+		return err
+	}
+
+	if len(contexts) == 0 && printer.Human() {
 		fmt.Println("No contexts available.")
-		fmt.Println("Use 'cldenv create <context>' to create a new context")
+		printHint("Use 'cldenv create <context>' to create a new context\n")
 		return nil
 	}
 
+	if listTag != "" {
+		tagged := []context.Context{}
+		for _, ctx := range contexts {
+			if slices.Contains(ctx.Tags, listTag) {
+				tagged = append(tagged, ctx)
+			}
+		}
+		if len(tagged) == 0 && printer.Human() {
+			fmt.Printf("No contexts tagged '%s'.\n", listTag)
+			return nil
+		}
+		contexts = tagged
+	}
+
+	// Most recently used first; contexts never used follow in name order
+	slices.SortStableFunc(contexts, func(a, b context.Context) int {
+		return b.LastUsed.Compare(a.LastUsed)
+	})
+
+	return printContexts(printer, contexts, func() {
+		printContextTable(manager, contexts, activeContext, local)
+	})
+}
+
+// printContexts prints a list of contexts, calling table for the human-readable output
+func printContexts(printer *output.Printer, contexts []context.Context, table func()) error {
+	if contexts == nil {
+		contexts = []context.Context{}
+	}
+	names := make([]string, len(contexts))
+	for i, ctx := range contexts {
+		names[i] = ctx.Name
+	}
+	return printer.Print(contexts, names, table)
+}
+
+// printContextTable prints the contexts for people, marking the active one
+func printContextTable(manager *context.Manager, contexts []context.Context, activeContext string, local *context.LocalContext) {
+
+	// Align last use and descriptions in columns after the names
+	labels := make([]string, len(contexts))
+	used := make([]string, len(contexts))
+	width, usedWidth := 0, 0
+	for i, ctx := range contexts {
+		labels[i] = ctx.Name
+		if ctx.Name == activeContext {
+			labels[i] += " (active)"
+		}
+		if local != nil && ctx.Name == local.Name {
+			labels[i] += " (directory)"
+		}
+		if ctx.Credentials {
+			labels[i] += " (credentials)"
+		}
+		width = max(width, len(labels[i]))
+		used[i] = relativeTime(ctx.LastUsed, "never used")
+		usedWidth = max(usedWidth, len(used[i]))
+	}
+
 	fmt.Println("Available contexts:")
-	for _, ctx := range contexts {
+	for i, ctx := range contexts {
 		marker := "  "
 		if ctx.Name == activeContext {
 			marker = "* "
 		}
-		
-		status := ""
-		if ctx.Name == activeContext {
-			status = " (active)"
+
+		details := ctx.Description
+		if len(ctx.Tags) > 0 {
+			details = strings.TrimSpace(details + " [" + strings.Join(ctx.Tags, ", ") + "]")
 		}
-		
-		fmt.Printf("%s%s%s\n", marker, ctx.Name, status)
+		if details == "" {
+			fmt.Printf("%s%-*s  %s\n", marker, width, labels[i], used[i])
+			continue
+		}
+		fmt.Printf("%s%-*s  %-*s  %s\n", marker, width, labels[i], usedWidth, used[i], details)
 	}
 
-	fmt.Println()
-	fmt.Println("Use 'cldenv use <context>' to switch context")
-	fmt.Println("Use 'cldenv create <context>' to create new context")
-	fmt.Println("Use 'cldenv remove <context>' to remove context")
+	if local != nil {
+		printLocalMismatch(manager, activeContext, local)
+	}
 
-	return nil
+	printHint("\nUse 'cldenv use <context>' to switch context\n")
+	printHint("Use 'cldenv create <context>' to create new context\n")
+	printHint("Use 'cldenv remove <context>' to remove context\n")
+}
+
+// findLocalContext resolves the context requested by the current directory
+func findLocalContext() (*context.LocalContext, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current directory: %w", err)
+	}
+
+	local, err := context.FindLocalContext(cwd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve directory context: %w", err)
+	}
+	return local, nil
+}
+
+// printLocalMismatch warns when the directory-requested context is not the active one
+func printLocalMismatch(manager *context.Manager, activeContext string, local *context.LocalContext) {
+	if !manager.ContextExists(local.Name) {
+		printHint("\n! Directory requests context '%s', which does not exist\n", local.Name)
+		return
+	}
+
+	if local.Name != activeContext {
+		printHint("\n! Directory requests context '%s' but '%s' is active\n", local.Name, activeContext)
+		printHint("Use 'cldenv use %s' to switch context\n", local.Name)
+	}
 }
\ No newline at end of file
@@ -0,0 +1,157 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/1outres/cldenv/internal/context"
+	"github.com/1outres/cldenv/pkg/passphrase"
+)
+
+// secretCmd represents the secret command
+var secretCmd = &cobra.Command{
+	Use:   "secret",
+	Short: "Keep API keys and tokens out of settings.json",
+	Long: `Keep API keys and tokens out of settings.json.
+
+Secrets are stored per context in secrets.enc, encrypted with a key derived
+from a passphrase, and referenced from settings.json as ${secret:NAME}.
+Layered contexts see the secrets of their parents. Switching to a context whose
+settings reference secrets writes a copy with the values filled in to a private
+runtime directory, $XDG_RUNTIME_DIR/cldenv/<context> (or $TMPDIR/cldenv-<uid>/
+<context> without it), readable only by you, and links ~/.claude/settings.json
+there. The context itself, its history and synced copies only ever hold the
+placeholders and the encrypted file.
+
+The passphrase is asked on the terminal, or read from $CLDENV_PASSPHRASE for
+scripts and 'cldenv watch'.`,
+	Example: `  cldenv secret set work ANTHROPIC_API_KEY
+  cldenv edit work settings.json   # "apiKeyHelper": "echo ${secret:ANTHROPIC_API_KEY}"
+  cldenv use work`,
+}
+
+// secretSetCmd represents the secret set command
+var secretSetCmd = &cobra.Command{
+	Use:   "set <context> <name>",
+	Short: "Store a secret in a context",
+	Long: `Store a secret in a context. The value is read from standard input when it
+is piped, or asked on the terminal without echoing it.`,
+	Example: `  cldenv secret set work ANTHROPIC_API_KEY
+  pass show anthropic | cldenv secret set work ANTHROPIC_API_KEY`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		contextName, secret := args[0], args[1]
+		if err := context.ValidateSecretName(secret); err != nil {
+			return err
+		}
+
+		manager, err := context.NewManager()
+		if err != nil {
+			return fmt.Errorf("failed to create context manager: %w", err)
+		}
+		if !manager.ContextExists(contextName) {
+			return fmt.Errorf("%w: '%s'", context.ErrContextNotFound, contextName)
+		}
+
+		value, err := readSecretValue(secret)
+		if err != nil {
+			return err
+		}
+
+		if err := manager.SetSecret(contextName, secret, value); err != nil {
+			return fmt.Errorf("failed to set secret %s of '%s': %w", secret, contextName, err)
+		}
+
+		printSuccess("✓ Stored secret %s in context '%s'\n", secret, contextName)
+		return printResult(secretResult{Context: contextName, Secret: secret, Stored: true}, []string{secret})
+	},
+}
+
+// secretListCmd represents the secret list command
+var secretListCmd = &cobra.Command{
+	Use:   "list <context>",
+	Short: "List the names of the secrets of a context",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		printer, err := newPrinter()
+		if err != nil {
+			return err
+		}
+
+		manager, err := context.NewManager()
+		if err != nil {
+			return fmt.Errorf("failed to create context manager: %w", err)
+		}
+
+		names, err := manager.SecretNames(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to list secrets of '%s': %w", args[0], err)
+		}
+
+		return printer.Print(names, names, func() {
+			if len(names) == 0 {
+				fmt.Printf("Context '%s' has no secrets\n", args[0])
+				return
+			}
+			for _, name := range names {
+				fmt.Println(name)
+			}
+		})
+	},
+}
+
+// secretRemoveCmd represents the secret remove command
+var secretRemoveCmd = &cobra.Command{
+	Use:   "remove <context> <name>",
+	Short: "Remove a secret from a context",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		manager, err := context.NewManager()
+		if err != nil {
+			return fmt.Errorf("failed to create context manager: %w", err)
+		}
+
+		if err := manager.RemoveSecret(args[0], args[1]); err != nil {
+			return fmt.Errorf("failed to remove secret %s of '%s': %w", args[1], args[0], err)
+		}
+
+		printSuccess("✓ Removed secret %s from context '%s'\n", args[1], args[0])
+		return printResult(secretResult{Context: args[0], Secret: args[1], Stored: false}, []string{args[1]})
+	},
+}
+
+// secretResult is the machine-readable output of secret set and remove; it
+// never includes the value
+type secretResult struct {
+	Context string `json:"context"`
+	Secret  string `json:"secret"`
+	// Stored reports whether the context now has the secret
+	Stored bool `json:"stored"`
+}
+
+// readSecretValue reads the value of a secret from piped standard input, or
+// from the terminal without echoing it
+func readSecretValue(secret string) (string, error) {
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice == 0 {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", fmt.Errorf("failed to read secret: %w", err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+
+	value, err := passphrase.Read(fmt.Sprintf("Value of %s: ", secret))
+	if err != nil {
+		return "", fmt.Errorf("%w; pipe the value to standard input", err)
+	}
+	return value, nil
+}
+
+func init() {
+	secretCmd.AddCommand(secretSetCmd)
+	secretCmd.AddCommand(secretListCmd)
+	secretCmd.AddCommand(secretRemoveCmd)
+}
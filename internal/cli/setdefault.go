@@ -0,0 +1,35 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/1outres/cldenv/internal/context"
+)
+
+// setDefaultCmd represents the set-default command
+var setDefaultCmd = &cobra.Command{
+	Use:   "set-default <context>",
+	Short: "Choose the default context",
+	Long: `Choose the context that is recreated if missing and linked when no other
+context is active. The default context cannot be removed or renamed.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		manager, err := context.NewManager()
+		if err != nil {
+			return fmt.Errorf("failed to create context manager: %w", err)
+		}
+
+		if err := manager.SetDefaultContext(args[0]); err != nil {
+			return fmt.Errorf("failed to set default context: %w", err)
+		}
+
+		printSuccess("✓ '%s' is now the default context\n", args[0])
+		return printResult(defaultResult{Default: args[0]}, []string{args[0]})
+	},
+}
+
+// defaultResult is the machine-readable output of the set-default command
+type defaultResult struct {
+	Default string `json:"default"`
+}
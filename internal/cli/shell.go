@@ -0,0 +1,36 @@
+package cli
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var shellForce bool
+
+// shellCmd represents the shell command
+var shellCmd = &cobra.Command{
+	Use:   "shell <context>",
+	Short: "Start a subshell under a context",
+	Long: `Start $SHELL with the variables in the env file of a context set and Claude
+Code using the context, without switching globally. It is the same as
+'cldenv exec <context> -- $SHELL'; leave the subshell with exit.
+
+The directory hook does not change the environment inside the subshell.`,
+	Example: `  cldenv shell client-a`,
+	Args:    cobra.ExactArgs(1),
+	Annotations: map[string]string{
+		noOutputAnnotation: "true",
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		shell := os.Getenv("SHELL")
+		if shell == "" {
+			shell = "/bin/sh"
+		}
+		return runUnderContext(args[0], []string{shell}, shellForce)
+	},
+}
+
+func init() {
+	shellCmd.Flags().BoolVar(&shellForce, "force", false, "start even if settings.json is invalid")
+}
@@ -0,0 +1,175 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/1outres/cldenv/internal/context"
+	"github.com/1outres/cldenv/internal/output"
+)
+
+var showTree bool
+
+// showCmd represents the show command
+var showCmd = &cobra.Command{
+	Use:   "show [context]",
+	Short: "Show details of a context or the inheritance tree",
+	Long: `Show details of a context, including the chain of contexts it inherits from.
+With --tree, show the inheritance graph of all contexts.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		printer, err := newPrinter()
+		if err != nil {
+			return err
+		}
+
+		manager, err := context.NewManager()
+		if err != nil {
+			return fmt.Errorf("failed to create context manager: %w", err)
+		}
+
+		if err := manager.LoadContexts(); err != nil {
+			return fmt.Errorf("failed to load contexts: %w", err)
+		}
+
+		if showTree {
+			if !printer.Human() {
+				// The contexts carry their parents, which is the tree as data
+				return printContexts(printer, manager.GetContexts(), nil)
+			}
+			return printInheritanceTree(manager)
+		}
+
+		contextName := manager.GetActiveContext()
+		if len(args) == 1 {
+			contextName = args[0]
+		}
+		if contextName == "" {
+			return fmt.Errorf("no active context; specify a context to show")
+		}
+
+		return showContext(printer, manager, contextName)
+	},
+}
+
+func init() {
+	showCmd.Flags().BoolVar(&showTree, "tree", false, "show the inheritance tree of all contexts")
+}
+
+// contextDetails is the machine-readable output of the show command
+type contextDetails struct {
+	context.Context
+	// Chain lists the context and the contexts it inherits from, nearest first
+	Chain []string `json:"chain"`
+}
+
+// showContext prints the details of a single context
+func showContext(printer *output.Printer, manager *context.Manager, contextName string) error {
+	ctx, err := findContext(manager, contextName)
+	if err != nil {
+		return err
+	}
+
+	chain, err := manager.ResolveChain(contextName)
+	if err != nil {
+		return fmt.Errorf("failed to resolve inheritance of '%s': %w", contextName, err)
+	}
+
+	details := contextDetails{Context: *ctx, Chain: chain}
+	return printer.Print(details, []string{ctx.Name}, func() {
+		fmt.Printf("Name:    %s\n", ctx.Name)
+		fmt.Printf("Path:    %s\n", ctx.Path)
+		if len(chain) > 1 {
+			fmt.Printf("Extends: %s\n", strings.Join(chain, " -> "))
+		}
+		if len(ctx.Files) > 0 {
+			fmt.Printf("Files:   %s\n", strings.Join(ctx.Files, ", "))
+		} else {
+			fmt.Println("Files:   (none)")
+		}
+		if ctx.IsActive {
+			fmt.Println("Active:  yes")
+		} else {
+			fmt.Println("Active:  no")
+		}
+	})
+}
+
+// findContext returns a context loaded by manager.LoadContexts
+func findContext(manager *context.Manager, contextName string) (*context.Context, error) {
+	for _, ctx := range manager.GetContexts() {
+		if ctx.Name == contextName {
+			return &ctx, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: '%s'", context.ErrContextNotFound, contextName)
+}
+
+// printInheritanceTree prints all contexts as a forest rooted at contexts without a parent
+func printInheritanceTree(manager *context.Manager) error {
+	contexts := manager.GetContexts()
+	activeContext := manager.GetActiveContext()
+
+	// Reject cycles before printing anything
+	for _, ctx := range contexts {
+		if _, err := manager.ResolveChain(ctx.Name); err != nil && errors.Is(err, context.ErrInheritanceCycle) {
+			return err
+		}
+	}
+
+	exists := make(map[string]bool)
+	children := make(map[string][]string)
+	for _, ctx := range contexts {
+		exists[ctx.Name] = true
+	}
+
+	var roots []string
+	for _, ctx := range contexts {
+		if ctx.Extends == "" || !exists[ctx.Extends] {
+			roots = append(roots, ctx.Name)
+			continue
+		}
+		children[ctx.Extends] = append(children[ctx.Extends], ctx.Name)
+	}
+
+	extends := make(map[string]string)
+	for _, ctx := range contexts {
+		extends[ctx.Name] = ctx.Extends
+	}
+
+	label := func(name string) string {
+		l := name
+		if name == activeContext {
+			l += " (active)"
+		}
+		if parent := extends[name]; parent != "" && !exists[parent] {
+			l += fmt.Sprintf(" (missing parent '%s')", parent)
+		}
+		return l
+	}
+
+	var walk func(name, prefix string)
+	walk = func(name, prefix string) {
+		kids := children[name]
+		sort.Strings(kids)
+		for i, kid := range kids {
+			branch, next := "├── ", "│   "
+			if i == len(kids)-1 {
+				branch, next = "└── ", "    "
+			}
+			fmt.Printf("%s%s%s\n", prefix, branch, label(kid))
+			walk(kid, prefix+next)
+		}
+	}
+
+	sort.Strings(roots)
+	for _, root := range roots {
+		fmt.Println(label(root))
+		walk(root, "")
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/1outres/cldenv/internal/context"
+)
+
+var (
+	syncRemote  string
+	syncExclude []string
+	syncInclude []string
+	syncOurs    []string
+	syncTheirs  []string
+)
+
+// syncCmd represents the sync command
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Sync contexts with a git remote",
+	Long: `Pull and push ~/.cldenv to a git remote so several machines share contexts.
+
+Each context is merged against the state of the previous sync. settings.json
+and other JSON files are merged key by key; other files must only have
+changed on one machine. Contexts with conflicts are left untouched and can be
+resolved with --ours or --theirs.
+
+Machine-local contexts can be excluded with --exclude.`,
+	Example: `  cldenv sync --remote git@example.com:me/cldenv.git
+  cldenv sync
+  cldenv sync --exclude scratch
+  cldenv sync --theirs work`,
+	Args:         cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		manager, err := context.NewManager()
+		if err != nil {
+			return fmt.Errorf("failed to create context manager: %w", err)
+		}
+
+		// Changing exclusions doesn't sync
+		if len(syncExclude) > 0 || len(syncInclude) > 0 {
+			for _, name := range syncExclude {
+				if err := manager.SetSyncExcluded(name, true); err != nil {
+					return fmt.Errorf("failed to exclude context '%s': %w", name, err)
+				}
+				printSuccess("✓ Context '%s' will not be synced\n", name)
+			}
+			for _, name := range syncInclude {
+				if err := manager.SetSyncExcluded(name, false); err != nil {
+					return fmt.Errorf("failed to include context '%s': %w", name, err)
+				}
+				printSuccess("✓ Context '%s' will be synced\n", name)
+			}
+			return printResult(syncExclusionResult{Excluded: syncExclude, Included: syncInclude}, slices.Concat(syncExclude, syncInclude))
+		}
+
+		if syncRemote != "" {
+			if err := manager.SetSyncRemote(syncRemote); err != nil {
+				return fmt.Errorf("failed to set sync remote: %w", err)
+			}
+		}
+
+		resolutions := make(map[string]context.Resolution)
+		for _, name := range syncOurs {
+			resolutions[name] = context.ResolveLocal
+		}
+		for _, name := range syncTheirs {
+			resolutions[name] = context.ResolveRemote
+		}
+
+		result, err := manager.Sync(resolutions)
+		if err != nil {
+			return fmt.Errorf("failed to sync: %w", err)
+		}
+
+		for _, name := range result.Pulled {
+			printSuccess("✓ Pulled '%s'\n", name)
+		}
+		for _, name := range result.Pushed {
+			printSuccess("✓ Pushed '%s'\n", name)
+		}
+		if len(result.Pulled) == 0 && len(result.Pushed) == 0 && len(result.Conflicts) == 0 {
+			printSuccess("✓ Already up to date\n")
+		}
+
+		conflicts := make([]string, 0, len(result.Conflicts))
+		for name := range result.Conflicts {
+			conflicts = append(conflicts, name)
+		}
+		sort.Strings(conflicts)
+
+		// The name format lists every context the sync touched
+		names := slices.Concat(result.Pulled, result.Pushed, conflicts)
+		slices.Sort(names)
+		if err := printResult(result, slices.Compact(names)); err != nil {
+			return err
+		}
+
+		if len(result.Conflicts) > 0 {
+			if humanOutput() {
+				for _, name := range conflicts {
+					fmt.Printf("✗ Conflict in '%s': %s\n", name, strings.Join(result.Conflicts[name], ", "))
+				}
+			}
+			printHint("Use 'cldenv sync --ours <context>' or 'cldenv sync --theirs <context>' to resolve\n")
+			return fmt.Errorf("%d context(s) have conflicts", len(result.Conflicts))
+		}
+
+		return nil
+	},
+}
+
+// syncExclusionResult is the machine-readable output of sync with --exclude or --include
+type syncExclusionResult struct {
+	Excluded []string `json:"excluded,omitempty"`
+	Included []string `json:"included,omitempty"`
+}
+
+func init() {
+	syncCmd.Flags().StringVar(&syncRemote, "remote", "", "set the git remote URL to sync with")
+	syncCmd.Flags().StringSliceVar(&syncExclude, "exclude", nil, "mark contexts as machine-local and never sync them")
+	syncCmd.Flags().StringSliceVar(&syncInclude, "include", nil, "sync contexts previously excluded")
+	syncCmd.Flags().StringSliceVar(&syncOurs, "ours", nil, "resolve conflicts in contexts by keeping the local version")
+	syncCmd.Flags().StringSliceVar(&syncTheirs, "theirs", nil, "resolve conflicts in contexts by taking the remote version")
+}
@@ -7,12 +7,18 @@ import (
 	"github.com/1outres/cldenv/internal/context"
 )
 
+var useForce bool
+
 // useCmd represents the use command
 var useCmd = &cobra.Command{
 	Use:   "use <context>",
 	Short: "Switch to a different context",
 	Long: `Switch to a different context by creating symbolic links from ~/.claude/ 
-to the specified context directory.`,
+to the specified context directory. 'cldenv use -' switches back to the
+previously used context.
+
+settings.json is validated first. Syntax errors prevent the switch unless
+--force is given; other problems are reported as warnings.`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		contextName := args[0]
@@ -22,33 +28,35 @@ to the specified context directory.`,
 			return fmt.Errorf("failed to create context manager: %w", err)
 		}
 
+		// Like 'cd -', toggle to the previously used context
+		if contextName == "-" {
+			if contextName, err = manager.PreviousContext(); err != nil {
+				return err
+			}
+		}
+
 		if err := manager.LoadContexts(); err != nil {
 			return fmt.Errorf("failed to load contexts: %w", err)
 		}
 
 		// Check if context exists
 		if !manager.ContextExists(contextName) {
-			fmt.Printf("Context '%s' not found.\n\n", contextName)
-			
-			// Show available contexts
-			contexts := manager.GetContexts()
-			if len(contexts) > 0 {
-				fmt.Println("Available contexts:")
-				for _, ctx := range contexts {
-					fmt.Printf("  %s\n", ctx.Name)
-				}
-			} else {
-				fmt.Println("No contexts available.")
-				fmt.Println("Use 'cldenv create <context>' to create a new context")
-			}
-			
-			return nil
+			printAvailableContexts(manager)
+			return fmt.Errorf("%w: '%s'", context.ErrContextNotFound, contextName)
 		}
 
-		// Check if already active
-		if manager.GetActiveContext() == contextName {
-			fmt.Printf("Already using context '%s'\n", contextName)
-			return nil
+		issues, err := manager.ValidateContext(contextName)
+		if err != nil {
+			return fmt.Errorf("failed to validate context '%s': %w", contextName, err)
+		}
+		if err := checkValidation(issues, useForce); err != nil {
+			return err
+		}
+
+		// Check if already active; layered contexts are re-rendered instead
+		if manager.GetActiveContext() == contextName && !manager.IsLayered(contextName) {
+			printSuccess("Already using context '%s'\n", contextName)
+			return printContextResult(manager, contextName)
 		}
 
 		// Switch to the context
@@ -56,7 +64,26 @@ to the specified context directory.`,
 			return fmt.Errorf("failed to switch to context '%s': %w", contextName, err)
 		}
 
-		fmt.Printf("✓ Switched to context '%s'\n", contextName)
-		return nil
+		printSuccess("✓ Switched to context '%s'\n", contextName)
+		return printContextResult(manager, contextName)
 	},
-}
\ No newline at end of file
+}
+
+// printAvailableContexts lists the existing contexts as a hint after a context was not found
+func printAvailableContexts(manager *context.Manager) {
+	contexts := manager.GetContexts()
+	if len(contexts) == 0 {
+		printHint("No contexts available.\n")
+		printHint("Use 'cldenv create <context>' to create a new context\n")
+		return
+	}
+
+	printHint("Available contexts:\n")
+	for _, ctx := range contexts {
+		printHint("  %s\n", ctx.Name)
+	}
+}
+
+func init() {
+	useCmd.Flags().BoolVar(&useForce, "force", false, "switch even if settings.json is invalid")
+}
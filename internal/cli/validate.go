@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/1outres/cldenv/internal/context"
+	"github.com/1outres/cldenv/internal/schema"
+)
+
+// validateCmd represents the validate command
+var validateCmd = &cobra.Command{
+	Use:   "validate [context]",
+	Short: "Check settings.json of contexts against the settings schema",
+	Long: `Check settings.json of a context, or of every context, against the schema of
+known Claude Code settings. Layered contexts are checked together with the
+contexts they inherit from.
+
+Syntax errors are errors; unknown or deprecated keys and values of the wrong
+type are warnings. The command exits with a non-zero status if errors are found.`,
+	Args:         cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		printer, err := newPrinter()
+		if err != nil {
+			return err
+		}
+
+		manager, err := context.NewManager()
+		if err != nil {
+			return fmt.Errorf("failed to create context manager: %w", err)
+		}
+
+		var names []string
+		if len(args) == 1 {
+			names = args
+		} else {
+			if err := manager.LoadContexts(); err != nil {
+				return fmt.Errorf("failed to load contexts: %w", err)
+			}
+			for _, ctx := range manager.GetContexts() {
+				names = append(names, ctx.Name)
+			}
+		}
+
+		all := []context.ValidationIssue{}
+		var invalid []string
+		results := make([][]context.ValidationIssue, len(names))
+		for i, name := range names {
+			issues, err := manager.ValidateContext(name)
+			if err != nil {
+				return fmt.Errorf("failed to validate context '%s': %w", name, err)
+			}
+
+			results[i] = issues
+			all = append(all, issues...)
+			if context.HasValidationErrors(issues) {
+				invalid = append(invalid, name)
+			}
+		}
+
+		// --output name lists the contexts that fail validation
+		err = printer.Print(all, invalid, func() {
+			for i, name := range names {
+				issues := results[i]
+				if len(issues) == 0 {
+					fmt.Printf("✓ %s\n", name)
+					continue
+				}
+
+				mark := "!"
+				if context.HasValidationErrors(issues) {
+					mark = "✗"
+				}
+				fmt.Printf("%s %s\n", mark, name)
+				for _, issue := range issues {
+					fmt.Printf("    %-7s %s\n", issue.Severity, issue)
+				}
+			}
+		})
+		if err != nil {
+			return err
+		}
+
+		if len(invalid) > 0 {
+			return fmt.Errorf("%w in %d context(s)", context.ErrInvalidSettings, len(invalid))
+		}
+		return nil
+	},
+}
+
+// checkValidation prints validation issues and fails on errors unless force is set
+func checkValidation(issues []context.ValidationIssue, force bool) error {
+	for _, issue := range issues {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", severityLabel(issue.Severity), issue)
+	}
+
+	if context.HasValidationErrors(issues) && !force {
+		return fmt.Errorf("%w, use --force to proceed anyway", context.ErrInvalidSettings)
+	}
+	return nil
+}
+
+// severityLabel returns the prefix used when printing an issue
+func severityLabel(severity schema.Severity) string {
+	if severity == schema.Error {
+		return "Error"
+	}
+	return "Warning"
+}
@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"github.com/1outres/cldenv/internal/context"
+)
+
+// watchCmd represents the watch command
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Keep links intact and capture edits in the foreground",
+	Long: `Watch ~/.claude and ~/.cldenv and react immediately when a managed link is
+replaced or deleted: content written over a link is adopted into the active
+context and the link is restored. Every event is logged to stderr.
+
+The command runs in the foreground until interrupted, which makes it suitable
+for a systemd user unit. It requires Linux (inotify).`,
+	Args: cobra.NoArgs,
+	Annotations: map[string]string{
+		skipDriftWarningAnnotation: "true",
+		noOutputAnnotation:         "true",
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		manager, err := context.NewManager()
+		if err != nil {
+			return fmt.Errorf("failed to create context manager: %w", err)
+		}
+
+		stop := make(chan struct{})
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-signals
+			close(stop)
+		}()
+
+		logger := log.New(os.Stderr, "cldenv: ", log.LstdFlags)
+		if err := manager.Watch(logger, stop); err != nil {
+			return fmt.Errorf("failed to watch: %w", err)
+		}
+		return nil
+	},
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"path/filepath"
+)
+
+const (
+	CommandsDir     = "commands"
+	AgentsDir       = "agents"
+	OutputStylesDir = "output-styles"
+	HooksDir        = "hooks"
+	CredentialsFile = ".credentials.json"
+)
+
+// Artifact describes a file or directory in ~/.claude that is managed per context
+type Artifact struct {
+	// Name is the file or directory name, relative to ~/.claude and the context directory
+	Name string
+	// Dir reports whether the artifact is a directory
+	Dir bool
+	// Required artifacts exist in every context; optional ones are only linked
+	// when the active context carries them
+	Required bool
+	// Sensitive artifacts hold secrets. They are kept private, left out of
+	// history, sync, export and diff, and never inherited.
+	Sensitive bool
+}
+
+// Artifacts is the registry of everything cldenv links from ~/.claude into a context
+var Artifacts = []Artifact{
+	{Name: ClaudeFile, Required: true},
+	{Name: SettingsFile, Required: true},
+	{Name: CommandsDir, Dir: true},
+	{Name: AgentsDir, Dir: true},
+	{Name: OutputStylesDir, Dir: true},
+	{Name: HooksDir, Dir: true},
+	{Name: CredentialsFile, Sensitive: true},
+}
+
+// DisplayName returns the artifact name as shown to users, with a trailing slash for directories
+func (a Artifact) DisplayName() string {
+	if a.Dir {
+		return a.Name + "/"
+	}
+	return a.Name
+}
+
+// GetClaudeArtifactPath returns the path of an artifact in the Claude directory
+func GetClaudeArtifactPath(a Artifact) (string, error) {
+	claudeDir, err := GetClaudeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(claudeDir, a.Name), nil
+}
+
+// IsSensitive reports whether a path relative to a context directory is a
+// sensitive artifact
+func IsSensitive(rel string) bool {
+	for _, artifact := range Artifacts {
+		if artifact.Sensitive && filepath.ToSlash(rel) == artifact.Name {
+			return true
+		}
+	}
+	return false
+}
@@ -5,8 +5,20 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"regexp"
 )
 
+// tempPattern matches the files cldenv writes before renaming them into place:
+// <name>.tmp-<pid>, or settings.tmp-<pid>.json while editing
+var tempPattern = regexp.MustCompile(`\.tmp-[0-9]+(\.json)?$`)
+
+// IsTempFile reports whether the last element of path is a temporary file
+// written by cldenv. Files the user named similarly, such as
+// notes.tmp-draft.md, are not.
+func IsTempFile(path string) bool {
+	return tempPattern.MatchString(filepath.Base(path))
+}
+
 // FileExists checks if a file exists
 func FileExists(path string) bool {
 	_, err := os.Stat(path)
@@ -60,6 +72,16 @@ func MoveFile(src, dst string) error {
 	}
 
 	// If rename fails, copy and delete
+	if info, err := os.Stat(src); err == nil && info.IsDir() {
+		if err := CopyDir(src, dst); err != nil {
+			return fmt.Errorf("failed to copy directory during move: %w", err)
+		}
+		if err := os.RemoveAll(src); err != nil {
+			return fmt.Errorf("failed to remove source directory during move: %w", err)
+		}
+		return nil
+	}
+
 	if err := CopyFile(src, dst); err != nil {
 		return fmt.Errorf("failed to copy file during move: %w", err)
 	}
@@ -71,6 +93,40 @@ func MoveFile(src, dst string) error {
 	return nil
 }
 
+// CopyDir recursively copies the contents of src into dst, overwriting existing files
+func CopyDir(src, dst string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		switch {
+		case info.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm()|0700)
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return fmt.Errorf("failed to read symlink: %w", err)
+			}
+			if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to replace file: %w", err)
+			}
+			return os.Symlink(link, target)
+		default:
+			if err := CopyFile(path, target); err != nil {
+				return err
+			}
+			return os.Chmod(target, info.Mode().Perm())
+		}
+	})
+}
+
 // RemoveFile removes a file if it exists
 func RemoveFile(path string) error {
 	if FileExists(path) {
@@ -0,0 +1,24 @@
+package config
+
+import "testing"
+
+func TestIsTempFile(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"settings.json.tmp-1234", true},
+		{"/home/me/.claude/CLAUDE.md.tmp-1", true},
+		{"settings.tmp-42.json", true},
+		{".work.tmp-99", true},
+		{"commands/foo.tmp-notes.md", false},
+		{"notes.tmp-", false},
+		{"settings.json", false},
+		{"backup.tmp-12.md", false},
+	}
+	for _, tt := range tests {
+		if got := IsTempFile(tt.path); got != tt.want {
+			t.Errorf("IsTempFile(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
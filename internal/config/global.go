@@ -0,0 +1,83 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// GlobalConfig holds settings that apply to all contexts, stored in ~/.cldenv/.config.json
+type GlobalConfig struct {
+	// DefaultContext is the context created on first run and used as a fallback
+	DefaultContext string `json:"default,omitempty"`
+	// Isolated gives every context its own Claude configuration directory
+	Isolated bool `json:"isolated,omitempty"`
+}
+
+// GetGlobalConfigPath returns the path of the global configuration file
+func GetGlobalConfigPath() (string, error) {
+	cldenvDir, err := GetCldenvDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cldenvDir, GlobalConfigFile), nil
+}
+
+// LoadGlobalConfig reads the global configuration, returning defaults if it does not exist
+func LoadGlobalConfig() (*GlobalConfig, error) {
+	path, err := GetGlobalConfigPath()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &GlobalConfig{}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// SaveGlobalConfig writes the global configuration
+func SaveGlobalConfig(cfg *GlobalConfig) error {
+	path, err := GetGlobalConfigPath()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode global config: %w", err)
+	}
+
+	if err := EnsureDir(path); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// GetDefaultContext returns the name of the default context, as configured or DefaultContext
+func GetDefaultContext() string {
+	cfg, err := LoadGlobalConfig()
+	if err != nil || cfg.DefaultContext == "" {
+		return DefaultContext
+	}
+	return cfg.DefaultContext
+}
+
+// IsIsolated reports whether cldenv runs in isolation mode
+func IsIsolated() bool {
+	cfg, err := LoadGlobalConfig()
+	return err == nil && cfg.Isolated
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 )
@@ -11,6 +12,22 @@ const (
 	ClaudeFile    = "CLAUDE.md"
 	SettingsFile  = "settings.json"
 	DefaultContext = "default"
+	LocalContextFile = ".cldenv-context"
+	MetadataFile  = "context.json"
+	EnvFile       = "env"
+	SecretsFile   = "secrets.enc"
+	RenderedDir   = ".rendered"
+	CurrentLink   = ".current"
+	LockFile      = ".lock"
+	TemplatesDir  = ".templates"
+	GlobalConfigFile = ".config.json"
+	PinsFile      = ".pins"
+	SwitchesFile  = ".switches"
+	LastUsedFile  = ".last-used"
+	ExecDir       = ".exec"
+	HomesDir      = ".homes"
+	HomeLink      = ".home"
+	ClaudeStateFile = ".claude.json"
 )
 
 // GetClaudeDir returns the Claude configuration directory path
@@ -31,6 +48,15 @@ func GetCldenvDir() (string, error) {
 	return filepath.Join(homeDir, CldenvDir), nil
 }
 
+// GetRuntimeDir returns the directory for files that must neither outlive the
+// session nor reach ~/.cldenv, such as settings with resolved secrets
+func GetRuntimeDir() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "cldenv")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("cldenv-%d", os.Getuid()))
+}
+
 // GetContextDir returns the path for a specific context
 func GetContextDir(contextName string) (string, error) {
 	cldenvDir, err := GetCldenvDir()
@@ -65,4 +91,22 @@ func GetContextFilePath(contextName, filename string) (string, error) {
 		return "", err
 	}
 	return filepath.Join(contextDir, filename), nil
+}
+
+// GetRenderedContextDir returns the directory where a layered context is materialized
+func GetRenderedContextDir(contextName string) (string, error) {
+	cldenvDir, err := GetCldenvDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cldenvDir, RenderedDir, contextName), nil
+}
+
+// GetCurrentLinkPath returns the path of the pointer to the active context
+func GetCurrentLinkPath() (string, error) {
+	cldenvDir, err := GetCldenvDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cldenvDir, CurrentLink), nil
 }
\ No newline at end of file
@@ -0,0 +1,106 @@
+package context
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/1outres/cldenv/internal/config"
+)
+
+var (
+	ErrLayeredContext = errors.New("context inherits from a parent")
+)
+
+// Drift is a managed artifact in ~/.claude that a tool replaced with a regular
+// file or directory while a context was active
+type Drift struct {
+	Artifact    config.Artifact
+	Context     string
+	ClaudePath  string
+	ContextPath string
+}
+
+// DetectDrift returns the managed artifacts that are no longer links into the active context
+func (m *Manager) DetectDrift() []Drift {
+	activeContext := m.getActiveContext()
+	if activeContext == "" {
+		return nil
+	}
+
+	var drifts []Drift
+	for _, artifact := range config.Artifacts {
+		claudePath, err := config.GetClaudeArtifactPath(artifact)
+		if err != nil {
+			continue
+		}
+
+		// Links are fine unless they lead to resolved settings that were edited
+		info, err := os.Lstat(claudePath)
+		if err != nil || (info.Mode()&os.ModeSymlink != 0 && !resolvedEdited(claudePath)) {
+			continue
+		}
+		// A login is only drift if the active context owns one
+		if artifact.Sensitive && !config.FileExists(filepath.Join(m.currentLinkPath(), artifact.Name)) {
+			continue
+		}
+
+		drifts = append(drifts, Drift{
+			Artifact:    artifact,
+			Context:     activeContext,
+			ClaudePath:  claudePath,
+			ContextPath: filepath.Join(m.cldenvDir, activeContext, artifact.Name),
+		})
+	}
+
+	return drifts
+}
+
+// Adopt moves the content that replaced a managed link into the active
+// context and restores the link
+func (m *Manager) Adopt(drift Drift) error {
+	unlock, err := m.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// Layered contexts link to rendered copies; adopting one would bake the
+	// parent's content into the child
+	if m.IsLayered(drift.Context) && !drift.Artifact.Sensitive {
+		return fmt.Errorf("%w: edit the files in %s instead", ErrLayeredContext, filepath.Dir(drift.ContextPath))
+	}
+
+	// Edited settings with resolved secrets go back with placeholders
+	if drift.Artifact.Name == config.SettingsFile && m.referencesSecrets(drift.ContextPath) {
+		if err := m.adoptResolvedSettings(drift); err != nil {
+			return err
+		}
+		m.record(fmt.Sprintf("adopt %s into %s", drift.Artifact.DisplayName(), drift.Context), drift.Context)
+		return m.SwitchContext(drift.Context)
+	}
+
+	if drift.Artifact.Dir {
+		if err := os.RemoveAll(drift.ContextPath); err != nil {
+			return fmt.Errorf("failed to replace %s in context: %w", drift.Artifact.DisplayName(), err)
+		}
+	}
+
+	if err := config.MoveFile(drift.ClaudePath, drift.ContextPath); err != nil {
+		return fmt.Errorf("failed to move %s into context '%s': %w", drift.Artifact.DisplayName(), drift.Context, err)
+	}
+	if drift.Artifact.Sensitive {
+		if err := os.Chmod(drift.ContextPath, credentialsMode); err != nil {
+			return fmt.Errorf("failed to protect %s: %w", drift.Artifact.DisplayName(), err)
+		}
+	}
+
+	m.record(fmt.Sprintf("adopt %s into %s", drift.Artifact.DisplayName(), drift.Context), drift.Context)
+
+	if err := m.SwitchContext(drift.Context); err != nil {
+		return fmt.Errorf("failed to restore link for %s: %w", drift.Artifact.DisplayName(), err)
+	}
+
+	return nil
+}
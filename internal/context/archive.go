@@ -0,0 +1,527 @@
+package context
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/1outres/cldenv/internal/config"
+)
+
+const (
+	// archiveFormat is the version of the archive layout written by Export
+	archiveFormat = 1
+
+	manifestEntry = "manifest.json"
+	contextsEntry = "contexts"
+
+	// maxArchiveFile bounds the size of a single archived file
+	maxArchiveFile = 16 << 20
+)
+
+var ErrInvalidArchive = errors.New("invalid archive")
+
+// Manifest describes the contents of an exported archive
+type Manifest struct {
+	Format   int               `json:"format"`
+	Version  string            `json:"version"`
+	Created  time.Time         `json:"created"`
+	Contexts []ArchivedContext `json:"contexts"`
+}
+
+// ArchivedContext describes one context in an archive
+type ArchivedContext struct {
+	Name    string         `json:"name"`
+	Extends string         `json:"extends,omitempty"`
+	Files   []ArchivedFile `json:"files"`
+}
+
+// ArchivedFile describes one file of an archived context
+type ArchivedFile struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Archive is a verified archive read into memory
+type Archive struct {
+	Manifest   Manifest
+	files      map[string]map[string][]byte
+	executable map[string]bool
+}
+
+// ImportItem describes what importing one archived context does
+type ImportItem struct {
+	Source  string `json:"source"`
+	Name    string `json:"name"`
+	Extends string `json:"extends,omitempty"`
+	Files   int    `json:"files"`
+	Exists  bool   `json:"exists"`
+}
+
+// Export writes the given contexts to w as a gzipped tar archive
+func (m *Manager) Export(w io.Writer, names []string, version string) error {
+	manifest := Manifest{
+		Format:  archiveFormat,
+		Version: version,
+		Created: time.Now().UTC().Truncate(time.Second),
+	}
+	contents := make(map[string]map[string][]byte)
+
+	for _, name := range names {
+		if err := ValidateContextName(name); err != nil {
+			return err
+		}
+		if _, ok := contents[name]; ok {
+			continue
+		}
+
+		files, err := m.Files(name)
+		if err != nil {
+			return fmt.Errorf("context '%s': %w", name, err)
+		}
+
+		meta, err := m.LoadMetadata(name)
+		if err != nil {
+			return err
+		}
+
+		archived := ArchivedContext{Name: name, Extends: meta.Extends, Files: []ArchivedFile{}}
+		for _, p := range sortedPaths(files) {
+			if config.IsTempFile(p) {
+				delete(files, p)
+				continue
+			}
+			sum := sha256.Sum256(files[p])
+			archived.Files = append(archived.Files, ArchivedFile{
+				Path:   p,
+				Size:   int64(len(files[p])),
+				SHA256: hex.EncodeToString(sum[:]),
+			})
+		}
+
+		manifest.Contexts = append(manifest.Contexts, archived)
+		contents[name] = files
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	if err := writeTarFile(tw, manifestEntry, append(data, '\n'), 0644, manifest.Created); err != nil {
+		return err
+	}
+	for _, ctx := range manifest.Contexts {
+		for _, file := range ctx.Files {
+			entry := path.Join(contextsEntry, ctx.Name, file.Path)
+			var mode int64 = 0644
+			if isExecutable(filepath.Join(m.cldenvDir, ctx.Name, filepath.FromSlash(file.Path))) {
+				mode = 0755
+			}
+			if err := writeTarFile(tw, entry, contents[ctx.Name][file.Path], mode, manifest.Created); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	return nil
+}
+
+// writeTarFile adds a regular file to a tar archive
+func writeTarFile(tw *tar.Writer, name string, content []byte, mode int64, modTime time.Time) error {
+	header := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     mode,
+		Size:     int64(len(content)),
+		ModTime:  modTime,
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write %s to archive: %w", name, err)
+	}
+	if _, err := tw.Write(content); err != nil {
+		return fmt.Errorf("failed to write %s to archive: %w", name, err)
+	}
+	return nil
+}
+
+// ReadArchive reads and verifies an archive written by Export. Entries other
+// than regular files, paths escaping the archive, sensitive files such as
+// logins and files that do not match the manifest are rejected. Executable
+// files stay executable.
+func ReadArchive(r io.Reader) (*Archive, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	defer gz.Close()
+
+	var manifest *Manifest
+	entries := make(map[string][]byte)
+	executable := make(map[string]bool)
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+		}
+
+		name, err := archiveEntryName(header.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			continue
+		case tar.TypeReg:
+		default:
+			return nil, fmt.Errorf("%w: %s is not a regular file", ErrInvalidArchive, header.Name)
+		}
+
+		if header.Size > maxArchiveFile {
+			return nil, fmt.Errorf("%w: %s is too large", ErrInvalidArchive, header.Name)
+		}
+		content, err := io.ReadAll(io.LimitReader(tr, maxArchiveFile))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+		}
+		if _, ok := entries[name]; ok {
+			return nil, fmt.Errorf("%w: duplicate entry %s", ErrInvalidArchive, name)
+		}
+
+		if name == manifestEntry {
+			manifest = &Manifest{}
+			if err := json.Unmarshal(content, manifest); err != nil {
+				return nil, fmt.Errorf("%w: malformed manifest: %v", ErrInvalidArchive, err)
+			}
+		}
+		entries[name] = content
+		executable[name] = header.Mode&0111 != 0
+	}
+
+	if manifest == nil {
+		return nil, fmt.Errorf("%w: missing %s", ErrInvalidArchive, manifestEntry)
+	}
+	if manifest.Format < 1 || manifest.Format > archiveFormat {
+		return nil, fmt.Errorf("%w: unsupported format %d, upgrade cldenv", ErrInvalidArchive, manifest.Format)
+	}
+	delete(entries, manifestEntry)
+
+	archive := &Archive{
+		Manifest:   *manifest,
+		files:      make(map[string]map[string][]byte),
+		executable: make(map[string]bool),
+	}
+	for _, ctx := range manifest.Contexts {
+		if err := ValidateContextName(ctx.Name); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+		}
+		if _, ok := archive.files[ctx.Name]; ok {
+			return nil, fmt.Errorf("%w: context '%s' listed twice", ErrInvalidArchive, ctx.Name)
+		}
+
+		files := make(map[string][]byte)
+		for _, file := range ctx.Files {
+			entry := path.Join(contextsEntry, ctx.Name, file.Path)
+			if !isLocalPath(file.Path) {
+				return nil, fmt.Errorf("%w: invalid path %s", ErrInvalidArchive, file.Path)
+			}
+			// Never let an archive plant a login that a switch would link
+			if config.IsSensitive(file.Path) {
+				return nil, fmt.Errorf("%w: refusing sensitive file %s", ErrInvalidArchive, entry)
+			}
+
+			content, ok := entries[entry]
+			if !ok {
+				return nil, fmt.Errorf("%w: %s is missing", ErrInvalidArchive, entry)
+			}
+			sum := sha256.Sum256(content)
+			if int64(len(content)) != file.Size || hex.EncodeToString(sum[:]) != file.SHA256 {
+				return nil, fmt.Errorf("%w: checksum mismatch for %s", ErrInvalidArchive, entry)
+			}
+
+			files[file.Path] = content
+			if executable[entry] {
+				archive.executable[path.Join(ctx.Name, file.Path)] = true
+			}
+			delete(entries, entry)
+		}
+		archive.files[ctx.Name] = files
+	}
+
+	for entry := range entries {
+		return nil, fmt.Errorf("%w: %s is not listed in the manifest", ErrInvalidArchive, entry)
+	}
+
+	return archive, nil
+}
+
+// archiveEntryName cleans an archive entry name and rejects names that would
+// escape the directory they are extracted to
+func archiveEntryName(name string) (string, error) {
+	clean := path.Clean(strings.TrimPrefix(name, "./"))
+	if path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") || strings.Contains(clean, "\\") {
+		return "", fmt.Errorf("%w: refusing path %s", ErrInvalidArchive, name)
+	}
+	if clean != manifestEntry && !strings.HasPrefix(clean, contextsEntry+"/") {
+		return "", fmt.Errorf("%w: unexpected entry %s", ErrInvalidArchive, name)
+	}
+	return clean, nil
+}
+
+// isLocalPath reports whether p is a clean relative path that stays below its root
+func isLocalPath(p string) bool {
+	return p != "" && p != "." && path.Clean(p) == p && !path.IsAbs(p) &&
+		p != ".." && !strings.HasPrefix(p, "../") && !strings.Contains(p, "\\")
+}
+
+// PlanImport describes how the contexts of an archive would be imported. With
+// as, a context is imported under another name: "<name>" renames the single
+// context of the archive, "<context>=<name>" one of several. Contexts in the
+// archive that extend the renamed one follow it.
+func (m *Manager) PlanImport(archive *Archive, as string) ([]ImportItem, error) {
+	contexts := archive.Manifest.Contexts
+
+	inArchive := make(map[string]bool)
+	for _, ctx := range contexts {
+		inArchive[ctx.Name] = true
+	}
+
+	renames := make(map[string]string)
+	if as != "" {
+		source, name, found := strings.Cut(as, "=")
+		if !found {
+			if len(contexts) != 1 {
+				return nil, fmt.Errorf("cannot rename: archive contains %d contexts; use <context>=<name>", len(contexts))
+			}
+			source, name = contexts[0].Name, as
+		}
+		if !inArchive[source] {
+			return nil, fmt.Errorf("cannot rename: archive does not contain '%s'", source)
+		}
+		renames[source] = name
+	}
+	rename := func(name string) string {
+		if renamed, ok := renames[name]; ok {
+			return renamed
+		}
+		return name
+	}
+
+	var items []ImportItem
+	seen := make(map[string]bool)
+	for _, ctx := range contexts {
+		name := rename(ctx.Name)
+		if err := ValidateContextName(name); err != nil {
+			return nil, err
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("cannot rename: archive already contains '%s'", name)
+		}
+		seen[name] = true
+
+		extends := ctx.Extends
+		if inArchive[extends] {
+			extends = rename(extends)
+		} else if extends != "" && !m.ContextExists(extends) {
+			return nil, fmt.Errorf("context '%s' extends '%s', which is neither in the archive nor installed", ctx.Name, ctx.Extends)
+		}
+		if extends == name {
+			return nil, fmt.Errorf("context '%s' cannot extend itself", name)
+		}
+
+		items = append(items, ImportItem{
+			Source:  ctx.Name,
+			Name:    name,
+			Extends: extends,
+			Files:   len(ctx.Files),
+			Exists:  m.ContextExists(name),
+		})
+	}
+
+	return items, nil
+}
+
+// withExtends returns the files of a context with the parent named in
+// context.json replaced, keeping the other metadata as archived
+func withExtends(files map[string][]byte, extends string) (map[string][]byte, error) {
+	meta := make(map[string]any)
+	if data, ok := files[config.MetadataFile]; ok {
+		if err := json.Unmarshal(data, &meta); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", config.MetadataFile, err)
+		}
+	}
+	if meta["extends"] == extends {
+		return files, nil
+	}
+	meta["extends"] = extends
+
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", config.MetadataFile, err)
+	}
+	result := maps.Clone(files)
+	result[config.MetadataFile] = append(data, '\n')
+	return result, nil
+}
+
+// Import writes the contexts of an archive. Existing contexts are only
+// replaced with overwrite.
+func (m *Manager) Import(archive *Archive, as string, overwrite bool) ([]ImportItem, error) {
+	unlock, err := m.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	items, err := m.PlanImport(archive, as)
+	if err != nil {
+		return nil, err
+	}
+
+	var conflicts []string
+	for _, item := range items {
+		if item.Exists {
+			conflicts = append(conflicts, item.Name)
+		}
+	}
+	if len(conflicts) > 0 && !overwrite {
+		return nil, fmt.Errorf("%w: %s", ErrContextAlreadyExists, strings.Join(conflicts, ", "))
+	}
+
+	var names []string
+	for _, item := range items {
+		files := archive.files[item.Source]
+		if item.Extends != "" {
+			if files, err = withExtends(files, item.Extends); err != nil {
+				return nil, fmt.Errorf("failed to import '%s': %w", item.Source, err)
+			}
+		}
+		executable := make(map[string]bool)
+		for p := range files {
+			executable[p] = archive.executable[path.Join(item.Source, p)]
+		}
+		if err := m.replaceContext(item.Name, files, executable); err != nil {
+			return nil, err
+		}
+		names = append(names, item.Name)
+	}
+
+	m.record(fmt.Sprintf("import %s", strings.Join(names, ", ")), names...)
+
+	// Refresh the links if the active context or one of its parents changed
+	if active := m.getActiveContext(); active != "" {
+		chain, err := m.ResolveChain(active)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range chain {
+			if slices.Contains(names, name) {
+				if err := m.SwitchContext(active); err != nil {
+					return nil, fmt.Errorf("failed to refresh active context '%s': %w", active, err)
+				}
+				break
+			}
+		}
+	}
+
+	return items, nil
+}
+
+// replaceContext writes files to a staging directory and renames it over the
+// context. Files listed in executable are made executable.
+func (m *Manager) replaceContext(name string, files map[string][]byte, executable map[string]bool) error {
+	contextPath := filepath.Join(m.cldenvDir, name)
+	staging := filepath.Join(m.cldenvDir, fmt.Sprintf(".%s.tmp-%d", name, os.Getpid()))
+	if err := os.RemoveAll(staging); err != nil {
+		return fmt.Errorf("failed to clear staging directory: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	if err := config.CreateDir(staging); err != nil {
+		return fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	for p, content := range files {
+		target := filepath.Join(staging, filepath.FromSlash(p))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", p, err)
+		}
+		var mode os.FileMode = 0644
+		if executable[p] {
+			mode = 0755
+		}
+		if err := os.WriteFile(target, content, mode); err != nil {
+			return fmt.Errorf("failed to write %s: %w", p, err)
+		}
+	}
+
+	// Archives never carry credentials; keep the ones the context has
+	if err := writeSensitive(staging, readSensitive(contextPath)); err != nil {
+		return err
+	}
+
+	old := staging + "-old"
+	if config.FileExists(contextPath) {
+		if err := os.Rename(contextPath, old); err != nil {
+			return fmt.Errorf("failed to replace context '%s': %w", name, err)
+		}
+		defer os.RemoveAll(old)
+	}
+
+	if err := os.Rename(staging, contextPath); err != nil {
+		if config.FileExists(old) {
+			os.Rename(old, contextPath)
+		}
+		return fmt.Errorf("failed to write context '%s': %w", name, err)
+	}
+
+	return nil
+}
+
+// sortedPaths returns the keys of a file map in order
+func sortedPaths(files map[string][]byte) []string {
+	paths := make([]string, 0, len(files))
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// ReadArchiveFile reads and verifies an archive from disk
+func ReadArchiveFile(file string) (*Archive, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", file, err)
+	}
+	defer f.Close()
+
+	return ReadArchive(f)
+}
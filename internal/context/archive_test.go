@@ -0,0 +1,176 @@
+package context
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/1outres/cldenv/internal/config"
+)
+
+func TestExportImportKeepsExecutableFiles(t *testing.T) {
+	m := newTestManager(t)
+	if err := m.CreateContext("work", CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	hooks := filepath.Join(m.cldenvDir, "work", "hooks")
+	if err := os.MkdirAll(hooks, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(hooks, "lint.sh"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(hooks, "notes.txt"), []byte("notes\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := m.Export(&buf, []string{"work"}, "test"); err != nil {
+		t.Fatal(err)
+	}
+	archive, err := ReadArchive(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Import(archive, "imported", false); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]bool{"lint.sh": true, "notes.txt": false} {
+		info, err := os.Stat(filepath.Join(m.cldenvDir, "imported", "hooks", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := info.Mode()&0111 != 0; got != want {
+			t.Errorf("%s: executable = %v, want %v (mode %v)", name, got, want, info.Mode())
+		}
+	}
+}
+
+func TestReadArchiveRejectsSensitiveFiles(t *testing.T) {
+	login := []byte(`{"claudeAiOauth":{}}`)
+	sum := sha256.Sum256(login)
+	manifest, err := json.Marshal(Manifest{
+		Format: archiveFormat,
+		Contexts: []ArchivedContext{{
+			Name: "work",
+			Files: []ArchivedFile{{
+				Path:   ".credentials.json",
+				Size:   int64(len(login)),
+				SHA256: hex.EncodeToString(sum[:]),
+			}},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range map[string][]byte{
+		manifestEntry: manifest,
+		contextsEntry + "/work/.credentials.json": login,
+	} {
+		if err := tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: name, Mode: 0600, Size: int64(len(content))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ReadArchive(&buf); !errors.Is(err, ErrInvalidArchive) {
+		t.Fatalf("ReadArchive() error = %v, want %v", err, ErrInvalidArchive)
+	}
+}
+
+func TestExportSkipsOnlyTempFiles(t *testing.T) {
+	m := newTestManager(t)
+	commands := filepath.Join(m.cldenvDir, config.DefaultContext, "commands")
+	if err := os.MkdirAll(commands, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"foo.tmp-notes.md", "bar.md.tmp-1234"} {
+		if err := os.WriteFile(filepath.Join(commands, name), []byte("x\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := m.Export(&buf, []string{config.DefaultContext}, "test"); err != nil {
+		t.Fatal(err)
+	}
+	archive, err := ReadArchive(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	paths := make(map[string]bool)
+	for _, file := range archive.Manifest.Contexts[0].Files {
+		paths[file.Path] = true
+	}
+	if !paths["commands/foo.tmp-notes.md"] {
+		t.Error("commands/foo.tmp-notes.md was left out")
+	}
+	if paths["commands/bar.md.tmp-1234"] {
+		t.Error("temp file commands/bar.md.tmp-1234 was exported")
+	}
+}
+
+func TestImportRenamedParent(t *testing.T) {
+	m := newTestManager(t)
+	if err := m.CreateContext("base", CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.CreateContext("work", CreateOptions{Extends: "base"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := m.Export(&buf, []string{"base", "work"}, "test"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.RemoveContext("work"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.RemoveContext("base"); err != nil {
+		t.Fatal(err)
+	}
+
+	archive, err := ReadArchive(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.PlanImport(archive, "team-base"); err == nil {
+		t.Error("PlanImport() renamed one of several contexts without naming it")
+	}
+	if _, err := m.Import(archive, "base=team-base", false); err != nil {
+		t.Fatal(err)
+	}
+
+	meta, err := m.LoadMetadata("work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Extends != "team-base" {
+		t.Errorf("work extends %q, want %q", meta.Extends, "team-base")
+	}
+	if m.ContextExists("base") {
+		t.Error("base was imported under its old name")
+	}
+}
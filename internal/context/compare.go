@@ -0,0 +1,193 @@
+package context
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/1outres/cldenv/internal/config"
+	"github.com/1outres/cldenv/pkg/jsondiff"
+	"github.com/1outres/cldenv/pkg/textdiff"
+)
+
+// FileStatus describes how a file differs between two sides
+type FileStatus string
+
+const (
+	FileAdded    FileStatus = "added"
+	FileRemoved  FileStatus = "removed"
+	FileModified FileStatus = "modified"
+)
+
+// FileDiff describes how one file differs between two sets of context files.
+// JSON files are compared key by key, other files line by line.
+type FileDiff struct {
+	Path    string            `json:"path"`
+	Status  FileStatus        `json:"status"`
+	Changes []jsondiff.Change `json:"changes,omitempty"`
+	Unified string            `json:"diff,omitempty"`
+}
+
+// CompareFiles returns the files that differ between a and b, ordered by path.
+// JSON files that only differ in formatting or key order are not reported.
+func CompareFiles(aName, bName string, a, b map[string][]byte) []FileDiff {
+	paths := make(map[string]bool)
+	for path := range a {
+		paths[path] = true
+	}
+	for path := range b {
+		paths[path] = true
+	}
+
+	sorted := make([]string, 0, len(paths))
+	for path := range paths {
+		sorted = append(sorted, path)
+	}
+	sort.Strings(sorted)
+
+	var diffs []FileDiff
+	for _, path := range sorted {
+		before, inA := a[path]
+		after, inB := b[path]
+
+		d := FileDiff{Path: path, Status: FileModified}
+		switch {
+		case !inA:
+			d.Status = FileAdded
+		case !inB:
+			d.Status = FileRemoved
+		case string(before) == string(after):
+			continue
+		}
+
+		if strings.HasSuffix(path, ".json") {
+			da, errA := parseDocument(before)
+			db, errB := parseDocument(after)
+			if errA == nil && errB == nil {
+				d.Changes = jsondiff.Diff(da, db)
+				if len(d.Changes) == 0 && d.Status == FileModified {
+					continue
+				}
+				diffs = append(diffs, d)
+				continue
+			}
+		}
+
+		d.Unified = textdiff.Unified(aName+"/"+path, bName+"/"+path, string(before), string(after))
+		diffs = append(diffs, d)
+	}
+
+	return diffs
+}
+
+// IsDirectorySide reports whether a side of a comparison names a directory:
+// anything containing a path separator or starting with ~ or .
+func IsDirectorySide(spec string) bool {
+	return strings.ContainsRune(spec, filepath.Separator) || strings.HasPrefix(spec, "~") || strings.HasPrefix(spec, ".")
+}
+
+// ReadSides returns the files of both sides of a comparison. When either side
+// is a directory, which only holds managed artifacts, both sides are limited
+// to those, so context metadata does not show up as a difference.
+func (m *Manager) ReadSides(aSpec, bSpec string) (map[string][]byte, map[string][]byte, error) {
+	a, err := m.ReadSide(aSpec)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read '%s': %w", aSpec, err)
+	}
+	b, err := m.ReadSide(bSpec)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read '%s': %w", bSpec, err)
+	}
+
+	if IsDirectorySide(aSpec) || IsDirectorySide(bSpec) {
+		a, b = onlyArtifacts(a), onlyArtifacts(b)
+	}
+	return a, b, nil
+}
+
+// onlyArtifacts returns the files that belong to a managed artifact
+func onlyArtifacts(files map[string][]byte) map[string][]byte {
+	result := make(map[string][]byte)
+	for path, content := range files {
+		top, _, _ := strings.Cut(path, "/")
+		for _, artifact := range config.Artifacts {
+			if top == artifact.Name && !artifact.Sensitive {
+				result[path] = content
+				break
+			}
+		}
+	}
+	return result
+}
+
+// ReadSide returns the files of one side of a comparison: a directory such as
+// ~/.claude, "<context>@<rev>" or a context
+func (m *Manager) ReadSide(spec string) (map[string][]byte, error) {
+	if IsDirectorySide(spec) {
+		dir := spec
+		if dir == "~" || strings.HasPrefix(dir, "~/") {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return nil, fmt.Errorf("failed to get home directory: %w", err)
+			}
+			dir = filepath.Join(home, strings.TrimPrefix(dir, "~"))
+		}
+		return ReadArtifacts(dir)
+	}
+
+	if name, rev, ok := ParseRevision(spec); ok {
+		return m.FilesAt(name, rev)
+	}
+
+	if err := ValidateContextName(spec); err != nil {
+		return nil, err
+	}
+	return m.Files(spec)
+}
+
+// ReadArtifacts reads the managed artifacts found in dir, following links, so
+// that reading ~/.claude returns the working state
+func ReadArtifacts(dir string) (map[string][]byte, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+
+	files := make(map[string][]byte)
+	for _, artifact := range config.Artifacts {
+		if artifact.Sensitive {
+			continue
+		}
+		root, err := filepath.EvalSymlinks(filepath.Join(dir, artifact.Name))
+		if err != nil {
+			// Missing artifacts and dangling links have no content
+			continue
+		}
+
+		err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil || !info.Mode().IsRegular() || config.IsTempFile(info.Name()) {
+				return err
+			}
+			rel, err := filepath.Rel(root, path)
+			if err != nil {
+				return err
+			}
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			files[filepath.ToSlash(filepath.Join(artifact.Name, rel))] = content
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", artifact.Name, err)
+		}
+	}
+
+	return files, nil
+}
@@ -0,0 +1,224 @@
+package context
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/1outres/cldenv/internal/config"
+)
+
+var ErrNoCredentials = errors.New("no credentials")
+
+// credentialsMode is the permission of credential files
+const credentialsMode = 0600
+
+// stashedCredentialsPath returns where the login no context owns is kept
+// while a context carrying credentials is active. In isolation mode every
+// home directory keeps its own, next to it.
+func (m *Manager) stashedCredentialsPath() string {
+	if config.IsIsolated() {
+		if home := m.activeHome(); home != "" {
+			return m.homeDir(home) + config.CredentialsFile
+		}
+	}
+	return filepath.Join(m.cldenvDir, config.CredentialsFile)
+}
+
+// checkCredentials refuses to switch away from a context carrying credentials
+// while a login Claude Code wrote over its link is not adopted. It runs before
+// isolation mode moves ~/.claude, while ~/.claude still belongs to the active
+// context; the login found in another home directory is that home's own.
+func (m *Manager) checkCredentials() error {
+	claudePath := filepath.Join(m.claudeDir, config.CredentialsFile)
+	info, err := os.Lstat(claudePath)
+	if err != nil || info.Mode()&os.ModeSymlink != 0 {
+		return nil
+	}
+
+	if config.FileExists(filepath.Join(m.currentLinkPath(), config.CredentialsFile)) {
+		return fmt.Errorf("%s was replaced by a regular file; run 'cldenv adopt' to keep the login", claudePath)
+	}
+	return nil
+}
+
+// stashCredentials prepares ~/.claude for a switch to dir: a login that no
+// context owns is moved out of the way when dir carries credentials. The
+// returned function undoes the move.
+func (m *Manager) stashCredentials(dir string) (func(), error) {
+	claudePath := filepath.Join(m.claudeDir, config.CredentialsFile)
+	info, err := os.Lstat(claudePath)
+	if err != nil || info.Mode()&os.ModeSymlink != 0 {
+		return func() {}, nil
+	}
+
+	if !config.FileExists(filepath.Join(dir, config.CredentialsFile)) {
+		return func() {}, nil
+	}
+
+	stash := m.stashedCredentialsPath()
+	if config.FileExists(stash) {
+		return nil, fmt.Errorf("a login is already stashed in %s; remove %s or %s first", stash, claudePath, stash)
+	}
+	if err := os.Rename(claudePath, stash); err != nil {
+		return nil, fmt.Errorf("failed to stash credentials: %w", err)
+	}
+	os.Chmod(stash, credentialsMode)
+	return func() { os.Rename(stash, claudePath) }, nil
+}
+
+// unstashCredentials puts the stashed login back once dir, which carries no
+// credentials, is active
+func (m *Manager) unstashCredentials(dir string) {
+	stash := m.stashedCredentialsPath()
+	if config.FileExists(filepath.Join(dir, config.CredentialsFile)) || !config.FileExists(stash) {
+		return
+	}
+
+	claudePath := filepath.Join(m.claudeDir, config.CredentialsFile)
+	if _, err := os.Lstat(claudePath); err == nil {
+		return
+	}
+	if err := os.Rename(stash, claudePath); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to restore the login stashed in %s: %v\n", stash, err)
+	}
+}
+
+// sharedCredentials returns the login a context without credentials uses:
+// the one no context owns, or in isolation mode the one in the home directory
+// of the context. It returns an empty string if there is none.
+func (m *Manager) sharedCredentials(name string) string {
+	claudeDir, stash := m.claudeDir, filepath.Join(m.cldenvDir, config.CredentialsFile)
+	if config.IsIsolated() {
+		claudeDir, stash = m.homeDir(name), m.homeDir(name)+config.CredentialsFile
+	}
+
+	if config.FileExists(stash) {
+		return stash
+	}
+	login := filepath.Join(claudeDir, config.CredentialsFile)
+	if info, err := os.Lstat(login); err == nil && info.Mode().IsRegular() {
+		return login
+	}
+	return ""
+}
+
+// SaveCredentials gives a context a copy of the current login, so switching
+// to it logs in with that account
+func (m *Manager) SaveCredentials(name string) error {
+	unlock, err := m.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if !m.ContextExists(name) {
+		return ErrContextNotFound
+	}
+
+	// The current login is whatever ~/.claude resolves to
+	src := filepath.Join(m.claudeDir, config.CredentialsFile)
+	content, err := os.ReadFile(src)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%w: %s does not exist; log in with Claude Code first", ErrNoCredentials, src)
+		}
+		return fmt.Errorf("failed to read credentials: %w", err)
+	}
+
+	dst := filepath.Join(m.cldenvDir, name, config.CredentialsFile)
+	owned := config.FileExists(dst)
+	if err := writeSensitive(filepath.Dir(dst), map[string][]byte{config.CredentialsFile: content}); err != nil {
+		return err
+	}
+
+	if m.getActiveContext() != name {
+		return nil
+	}
+
+	// A regular file in ~/.claude is either a login Claude Code wrote over the
+	// link, now saved, or the login no context owns, kept for other contexts
+	if info, err := os.Lstat(src); err == nil && info.Mode().IsRegular() {
+		if owned {
+			err = os.Remove(src)
+		} else if config.FileExists(m.stashedCredentialsPath()) {
+			err = fmt.Errorf("a login is already stashed in %s", m.stashedCredentialsPath())
+		} else if err = os.Rename(src, m.stashedCredentialsPath()); err == nil {
+			err = os.Chmod(m.stashedCredentialsPath(), credentialsMode)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to replace %s with a link: %w", src, err)
+		}
+	}
+	return m.SwitchContext(name)
+}
+
+// RemoveCredentials deletes the login of a context. Switching to it then
+// uses the login no context owns.
+func (m *Manager) RemoveCredentials(name string) error {
+	unlock, err := m.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if !m.ContextExists(name) {
+		return ErrContextNotFound
+	}
+
+	path := filepath.Join(m.cldenvDir, name, config.CredentialsFile)
+	if !config.FileExists(path) {
+		return fmt.Errorf("%w: context '%s' has no credentials", ErrNoCredentials, name)
+	}
+
+	// Drop the link first so ~/.claude never points at a missing login
+	active := m.getActiveContext() == name
+	if active {
+		claudePath := filepath.Join(m.claudeDir, config.CredentialsFile)
+		if m.isManagedLink(claudePath) {
+			if err := os.Remove(claudePath); err != nil {
+				return fmt.Errorf("failed to remove link %s: %w", claudePath, err)
+			}
+		}
+	}
+
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove credentials: %w", err)
+	}
+
+	if active {
+		return m.SwitchContext(name)
+	}
+	return nil
+}
+
+// readSensitive reads the sensitive files of a context directory, which are
+// not part of its history, so they can survive a rollback or an import
+func readSensitive(dir string) map[string][]byte {
+	files := make(map[string][]byte)
+	for _, artifact := range config.Artifacts {
+		if !artifact.Sensitive {
+			continue
+		}
+		if content, err := os.ReadFile(filepath.Join(dir, artifact.Name)); err == nil {
+			files[artifact.Name] = content
+		}
+	}
+	return files
+}
+
+// writeSensitive writes files read by readSensitive back into a context directory
+func writeSensitive(dir string, files map[string][]byte) error {
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, content, credentialsMode); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+		// WriteFile keeps the permissions of an existing file
+		if err := os.Chmod(path, credentialsMode); err != nil {
+			return fmt.Errorf("failed to protect %s: %w", name, err)
+		}
+	}
+	return nil
+}
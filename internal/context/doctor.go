@@ -0,0 +1,411 @@
+package context
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/1outres/cldenv/internal/config"
+	"github.com/1outres/cldenv/internal/schema"
+	"github.com/1outres/cldenv/pkg/symlink"
+)
+
+// Severity classifies a doctor finding
+type Severity string
+
+const (
+	SeverityWarning Severity = "warning"
+	SeverityError   Severity = "error"
+)
+
+// Finding codes reported by Diagnose
+const (
+	CodeLinkMismatch     = "link-mismatch"
+	CodeDanglingLink     = "dangling-link"
+	CodeMissingLink      = "missing-link"
+	CodeNotSymlink       = "not-symlink"
+	CodeOutsideLink      = "outside-link"
+	CodeInvalidJSON      = "invalid-json"
+	CodeEmptySettings    = "empty-settings"
+	CodeMissingFile      = "missing-file"
+	CodeStrayBackup      = "stray-backup"
+	CodeInheritanceError = "inheritance-error"
+	CodeSettingsSchema   = "settings-schema"
+	CodeIsolationBroken  = "isolation-broken"
+	CodeHomeMismatch     = "home-mismatch"
+	CodeCredentialsMode  = "credentials-mode"
+	CodeInvalidEnv       = "invalid-env"
+)
+
+// Finding is a problem detected by Diagnose
+type Finding struct {
+	Code     string   `json:"code"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	Path     string   `json:"path,omitempty"`
+	Fixable  bool     `json:"fixable"`
+
+	fix func() error
+}
+
+// Diagnose inspects ~/.claude and ~/.cldenv for broken state
+func (m *Manager) Diagnose() ([]Finding, error) {
+	if err := m.LoadContexts(); err != nil {
+		return nil, err
+	}
+
+	var findings []Finding
+	findings = append(findings, m.diagnoseIsolation()...)
+	findings = append(findings, m.diagnoseLinks()...)
+	for _, ctx := range m.contexts {
+		findings = append(findings, m.diagnoseContext(ctx)...)
+	}
+	return findings, nil
+}
+
+// Fix repairs the fixable findings and returns the ones that were repaired
+func (m *Manager) Fix(findings []Finding) ([]Finding, error) {
+	unlock, err := m.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	var fixed []Finding
+	var errs []error
+	for _, finding := range findings {
+		if !finding.Fixable || finding.fix == nil {
+			continue
+		}
+		if err := finding.fix(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", finding.Code, err))
+			continue
+		}
+		fixed = append(fixed, finding)
+	}
+
+	return fixed, errors.Join(errs...)
+}
+
+// diagnoseLinks checks the links in ~/.claude and the .current pointer
+func (m *Manager) diagnoseLinks() []Finding {
+	var findings []Finding
+
+	currentPath := m.currentLinkPath()
+	pointerContext := ""
+	if config.IsSymlink(currentPath) {
+		target, _ := symlink.ReadSymlink(currentPath)
+		pointerContext = m.extractContextName(target)
+		switch {
+		case !symlink.IsValidSymlink(currentPath):
+			findings = append(findings, Finding{
+				Code:     CodeDanglingLink,
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("context pointer targets missing %s", target),
+				Path:     currentPath,
+				Fixable:  true,
+				fix:      m.relinkActive,
+			})
+		case pointerContext == "":
+			findings = append(findings, Finding{
+				Code:     CodeOutsideLink,
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("context pointer targets %s outside %s", target, m.cldenvDir),
+				Path:     currentPath,
+			})
+		}
+	}
+
+	linkedContexts := make(map[string][]string)
+	for _, artifact := range config.Artifacts {
+		claudePath, err := config.GetClaudeArtifactPath(artifact)
+		if err != nil {
+			continue
+		}
+
+		findings = append(findings, m.diagnoseBackup(artifact, claudePath)...)
+
+		info, err := os.Lstat(claudePath)
+		if err != nil {
+			if os.IsNotExist(err) && artifact.Required {
+				findings = append(findings, Finding{
+					Code:     CodeMissingLink,
+					Severity: SeverityError,
+					Message:  fmt.Sprintf("%s is missing", artifact.DisplayName()),
+					Path:     claudePath,
+					Fixable:  true,
+					fix:      m.relinkActive,
+				})
+			}
+			continue
+		}
+
+		// The login no context owns is expected to be a regular file
+		if artifact.Sensitive && info.Mode().IsRegular() && !config.FileExists(filepath.Join(currentPath, artifact.Name)) {
+			continue
+		}
+
+		// Settings with resolved secrets link into the runtime directory
+		if target, ok := resolvedTarget(claudePath); ok {
+			if !symlink.IsValidSymlink(claudePath) {
+				findings = append(findings, Finding{
+					Code:     CodeDanglingLink,
+					Severity: SeverityError,
+					Message:  fmt.Sprintf("%s links to resolved secrets that no longer exist, e.g. after a reboot", artifact.DisplayName()),
+					Path:     claudePath,
+					Fixable:  true,
+					fix:      m.relinkActive,
+				})
+			} else if resolvedEdited(claudePath) {
+				findings = append(findings, Finding{
+					Code:     CodeNotSymlink,
+					Severity: SeverityError,
+					Message:  fmt.Sprintf("%s with resolved secrets was edited in %s; run 'cldenv adopt'", artifact.DisplayName(), target),
+					Path:     claudePath,
+				})
+			}
+			continue
+		}
+
+		if info.Mode()&os.ModeSymlink == 0 {
+			findings = append(findings, Finding{
+				Code:     CodeNotSymlink,
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("%s is a regular %s instead of a link to a context; run 'cldenv adopt'", artifact.DisplayName(), kindOf(info)),
+				Path:     claudePath,
+			})
+			continue
+		}
+
+		target, err := symlink.ReadSymlink(claudePath)
+		if err != nil {
+			continue
+		}
+
+		if !m.isManagedLink(claudePath) {
+			findings = append(findings, Finding{
+				Code:     CodeOutsideLink,
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("%s links to %s outside %s", artifact.DisplayName(), target, m.cldenvDir),
+				Path:     claudePath,
+			})
+			continue
+		}
+
+		// A dangling pointer is reported once above
+		viaPointer := target == filepath.Join(currentPath, artifact.Name)
+		if !symlink.IsValidSymlink(claudePath) && !(viaPointer && !symlink.IsValidSymlink(currentPath)) {
+			finding := Finding{
+				Code:     CodeDanglingLink,
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("%s links to missing %s", artifact.DisplayName(), target),
+				Path:     claudePath,
+				Fixable:  true,
+				fix:      m.relinkActive,
+			}
+			if !artifact.Required {
+				finding.Severity = SeverityWarning
+				finding.fix = func() error { return symlink.RemoveSymlink(claudePath) }
+			}
+			findings = append(findings, finding)
+		}
+
+		// Links routed through the pointer belong to the pointer's context
+		name := m.extractContextName(target)
+		if viaPointer {
+			name = pointerContext
+		}
+		if name != "" {
+			linkedContexts[name] = append(linkedContexts[name], artifact.DisplayName())
+		}
+	}
+
+	if len(linkedContexts) > 1 {
+		var parts []string
+		for name, artifacts := range linkedContexts {
+			parts = append(parts, fmt.Sprintf("%s -> '%s'", strings.Join(artifacts, ", "), name))
+		}
+		sort.Strings(parts)
+		findings = append(findings, Finding{
+			Code:     CodeLinkMismatch,
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("managed files point at different contexts: %s", strings.Join(parts, "; ")),
+			Path:     m.claudeDir,
+			Fixable:  true,
+			fix:      m.relinkActive,
+		})
+	}
+
+	return findings
+}
+
+// diagnoseBackup reports backup files left next to a managed artifact
+func (m *Manager) diagnoseBackup(artifact config.Artifact, claudePath string) []Finding {
+	backupPath := claudePath + ".backup"
+	if _, err := os.Lstat(backupPath); err != nil {
+		return nil
+	}
+
+	return []Finding{{
+		Code:     CodeStrayBackup,
+		Severity: SeverityWarning,
+		Message:  fmt.Sprintf("stray backup of %s; review and remove it", artifact.DisplayName()),
+		Path:     backupPath,
+	}}
+}
+
+// diagnoseContext checks the files of a single context
+func (m *Manager) diagnoseContext(ctx Context) []Finding {
+	var findings []Finding
+
+	chain, err := m.ResolveChain(ctx.Name)
+	if err != nil {
+		findings = append(findings, Finding{
+			Code:     CodeInheritanceError,
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("context '%s': %v", ctx.Name, err),
+			Path:     filepath.Join(ctx.Path, config.MetadataFile),
+		})
+	}
+	layered := len(chain) > 1
+
+	if _, err := m.readEnv(ctx.Name); err != nil {
+		findings = append(findings, Finding{
+			Code:     CodeInvalidEnv,
+			Severity: SeverityError,
+			Message:  err.Error(),
+			Path:     filepath.Join(ctx.Path, config.EnvFile),
+		})
+	}
+
+	for _, artifact := range config.Artifacts {
+		if artifact.Sensitive {
+			findings = append(findings, m.diagnoseSensitive(ctx, artifact)...)
+		}
+		if !artifact.Required {
+			continue
+		}
+
+		path := filepath.Join(ctx.Path, artifact.Name)
+		if !config.FileExists(path) {
+			// Layered contexts may inherit the file from their parent
+			if layered {
+				continue
+			}
+			findings = append(findings, Finding{
+				Code:     CodeMissingFile,
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("context '%s' has no %s", ctx.Name, artifact.DisplayName()),
+				Path:     path,
+				Fixable:  true,
+				fix:      func() error { return createEmptyArtifact(path, artifact) },
+			})
+			continue
+		}
+
+		if artifact.Name != config.SettingsFile {
+			continue
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+
+		if len(bytes.TrimSpace(data)) == 0 {
+			findings = append(findings, Finding{
+				Code:     CodeEmptySettings,
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("settings.json of context '%s' is empty", ctx.Name),
+				Path:     path,
+				Fixable:  true,
+				fix:      func() error { return os.WriteFile(path, []byte("{}\n"), 0644) },
+			})
+			continue
+		}
+
+		for _, issue := range schema.ValidateSettings(data) {
+			if issue.Severity == schema.Error {
+				findings = append(findings, Finding{
+					Code:     CodeInvalidJSON,
+					Severity: SeverityError,
+					Message:  fmt.Sprintf("settings.json of context '%s' is not valid: %s", ctx.Name, issue),
+					Path:     path,
+				})
+				continue
+			}
+			findings = append(findings, Finding{
+				Code:     CodeSettingsSchema,
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("settings.json of context '%s': %s", ctx.Name, issue),
+				Path:     path,
+			})
+		}
+	}
+
+	return findings
+}
+
+// diagnoseSensitive checks that a sensitive artifact of a context is private
+func (m *Manager) diagnoseSensitive(ctx Context, artifact config.Artifact) []Finding {
+	path := filepath.Join(ctx.Path, artifact.Name)
+	info, err := os.Stat(path)
+	if err != nil || info.Mode().Perm()&0077 == 0 {
+		return nil
+	}
+
+	return []Finding{{
+		Code:     CodeCredentialsMode,
+		Severity: SeverityError,
+		Message:  fmt.Sprintf("%s of context '%s' is readable by other users (mode %04o)", artifact.DisplayName(), ctx.Name, info.Mode().Perm()),
+		Path:     path,
+		Fixable:  true,
+		fix:      func() error { return os.Chmod(path, credentialsMode) },
+	}}
+}
+
+// relinkActive recreates all links for the active context, falling back to the default context
+func (m *Manager) relinkActive() error {
+	name := m.getActiveContext()
+	if name == "" || !m.ContextExists(name) {
+		name = config.GetDefaultContext()
+	}
+
+	// Fill in required files missing from the context, e.g. one created empty
+	if !m.IsLayered(name) {
+		for _, artifact := range config.Artifacts {
+			path := filepath.Join(m.cldenvDir, name, artifact.Name)
+			if artifact.Required && !config.FileExists(path) {
+				if err := createEmptyArtifact(path, artifact); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return m.SwitchContext(name)
+}
+
+// createEmptyArtifact creates an empty but valid artifact at path
+func createEmptyArtifact(path string, artifact config.Artifact) error {
+	if artifact.Dir {
+		return config.CreateDir(path)
+	}
+
+	var content []byte
+	if artifact.Name == config.SettingsFile {
+		content = []byte("{}\n")
+	}
+	return os.WriteFile(path, content, 0644)
+}
+
+func kindOf(info os.FileInfo) string {
+	if info.IsDir() {
+		return "directory"
+	}
+	return "file"
+}
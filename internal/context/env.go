@@ -0,0 +1,55 @@
+package context
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/1outres/cldenv/internal/config"
+	"github.com/1outres/cldenv/pkg/envfile"
+)
+
+var ErrInvalidEnv = errors.New("invalid env file")
+
+// Env returns the environment variables of a context, read from the env file
+// of every context along its inheritance chain with children overriding
+// their parents
+func (m *Manager) Env(name string) ([]envfile.Var, error) {
+	if !m.ContextExists(name) {
+		return nil, ErrContextNotFound
+	}
+
+	chain, err := m.ResolveChain(name)
+	if err != nil {
+		return nil, err
+	}
+
+	vars := []envfile.Var{}
+	for _, ancestor := range chain {
+		own, err := m.readEnv(ancestor)
+		if err != nil {
+			return nil, err
+		}
+		vars = envfile.Merge(vars, own)
+	}
+	return vars, nil
+}
+
+// readEnv parses the env file of a single context
+func (m *Manager) readEnv(name string) ([]envfile.Var, error) {
+	path := filepath.Join(m.cldenvDir, name, config.EnvFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read env file of '%s': %w", name, err)
+	}
+
+	vars, err := envfile.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: env file of '%s': %v", ErrInvalidEnv, name, err)
+	}
+	return vars, nil
+}
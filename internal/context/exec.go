@@ -0,0 +1,225 @@
+package context
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/1outres/cldenv/internal/config"
+	"github.com/1outres/cldenv/pkg/symlink"
+)
+
+// PrepareConfigDir builds a private Claude configuration directory in
+// ~/.cldenv/.exec for running a single process under a context, leaving
+// ~/.claude untouched. The artifacts come from the context, rendered if it is
+// layered; everything else in ~/.claude, such as credentials and session
+// history, is linked from there. Settings with resolved secrets are written to
+// the runtime directory instead. The returned function removes both, after
+// moving what the process created in the directory to where it belongs, see
+// keepNewEntries.
+func (m *Manager) PrepareConfigDir(name string) (string, func(), error) {
+	unlock, err := m.lock()
+	if err != nil {
+		return "", nil, err
+	}
+	defer unlock()
+
+	if !m.ContextExists(name) {
+		return "", nil, ErrContextNotFound
+	}
+	chain, err := m.ResolveChain(name)
+	if err != nil {
+		return "", nil, err
+	}
+
+	root := filepath.Join(m.cldenvDir, config.ExecDir)
+	if err := config.CreateDir(root); err != nil {
+		return "", nil, fmt.Errorf("failed to create exec directory: %w", err)
+	}
+	dir, err := os.MkdirTemp(root, name+"-")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create config directory: %w", err)
+	}
+	// Resolved secrets only live as long as the directory, outside ~/.cldenv
+	resolvedDir := filepath.Join(config.GetRuntimeDir(), config.ExecDir, filepath.Base(dir))
+	remove := func() {
+		os.RemoveAll(dir)
+		os.RemoveAll(resolvedDir)
+	}
+
+	if err := m.populateConfigDir(chain, dir); err != nil {
+		remove()
+		return "", nil, err
+	}
+
+	settings, err := m.resolvedSettings(name, dir)
+	if err == nil && settings != nil {
+		resolved := filepath.Join(resolvedDir, config.SettingsFile)
+		if err = m.writeResolvedSettings(resolved, settings); err == nil {
+			err = symlink.CreateSymlink(resolved, filepath.Join(dir, config.SettingsFile))
+		}
+	}
+	if err != nil {
+		remove()
+		return "", nil, fmt.Errorf("failed to resolve secrets: %w", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		remove()
+		return "", nil, fmt.Errorf("failed to read config directory: %w", err)
+	}
+	known := make(map[string]bool)
+	for _, entry := range entries {
+		known[entry.Name()] = true
+	}
+	cleanup := func() {
+		m.keepNewEntries(name, dir, known)
+		remove()
+	}
+	return dir, cleanup, nil
+}
+
+// keepNewEntries moves the files and directories a process created in its
+// configuration directory out before the directory is removed. Artifacts
+// such as commands/ go to the context, the rest of the Claude state to the
+// shared directory. Entries that already exist there are dropped with a
+// warning.
+func (m *Manager) keepNewEntries(name, dir string, known map[string]bool) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	unlock, err := m.lock()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to keep files created under '%s': %v\n", name, err)
+		return
+	}
+	defer unlock()
+
+	managed := make(map[string]bool)
+	for _, artifact := range config.Artifacts {
+		managed[artifact.Name] = true
+	}
+	shared, err := m.sharedDir(name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to keep files created under '%s': %v\n", name, err)
+		return
+	}
+
+	var adopted []string
+	for _, entry := range entries {
+		if known[entry.Name()] || entry.Type()&os.ModeSymlink != 0 {
+			continue
+		}
+
+		var target string
+		switch {
+		case managed[entry.Name()]:
+			if !m.ContextExists(name) {
+				continue
+			}
+			target = filepath.Join(m.cldenvDir, name, entry.Name())
+		case entry.Name() == config.ClaudeStateFile:
+			target = filepath.Join(filepath.Dir(m.claudeDir), config.ClaudeStateFile)
+		default:
+			target = filepath.Join(shared, entry.Name())
+		}
+
+		if _, err := os.Lstat(target); err == nil {
+			fmt.Fprintf(os.Stderr, "Warning: dropping %s created under '%s': %s already exists\n", entry.Name(), name, target)
+			continue
+		}
+		if err := os.Rename(filepath.Join(dir, entry.Name()), target); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to keep %s created under '%s': %v\n", entry.Name(), name, err)
+			continue
+		}
+		if managed[entry.Name()] {
+			adopted = append(adopted, entry.Name())
+		}
+	}
+
+	if len(adopted) > 0 {
+		m.record(fmt.Sprintf("exec %s: add %s", name, strings.Join(adopted, ", ")), name)
+	}
+}
+
+// sharedDir returns the directory whose Claude state a process run under a
+// context shares: ~/.claude, or in isolation mode the home of the context
+func (m *Manager) sharedDir(name string) (string, error) {
+	if !config.IsIsolated() {
+		return m.claudeDir, nil
+	}
+	shared := m.homeDir(name)
+	if err := os.MkdirAll(shared, 0700); err != nil {
+		return "", fmt.Errorf("failed to create home directory: %w", err)
+	}
+	return shared, nil
+}
+
+// populateConfigDir fills a private configuration directory for a context chain
+func (m *Manager) populateConfigDir(chain []string, dir string) error {
+	managed := make(map[string]bool)
+	for _, artifact := range config.Artifacts {
+		managed[artifact.Name] = true
+	}
+
+	if len(chain) > 1 {
+		if err := m.renderChain(chain, dir); err != nil {
+			return err
+		}
+	} else {
+		// Link rather than copy, so edits made by Claude Code land in the context
+		contextPath := filepath.Join(m.cldenvDir, chain[0])
+		for _, artifact := range config.Artifacts {
+			src := filepath.Join(contextPath, artifact.Name)
+			if !config.FileExists(src) {
+				continue
+			}
+			if err := os.Symlink(src, filepath.Join(dir, artifact.Name)); err != nil {
+				return fmt.Errorf("failed to link %s: %w", artifact.DisplayName(), err)
+			}
+		}
+	}
+
+	// Share the rest of the Claude state with ~/.claude, or in isolation mode
+	// with the home directory of the context
+	shared, err := m.sharedDir(chain[len(chain)-1])
+	if err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(shared)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read claude directory: %w", err)
+	}
+	for _, entry := range entries {
+		if managed[entry.Name()] {
+			continue
+		}
+		if err := os.Symlink(filepath.Join(shared, entry.Name()), filepath.Join(dir, entry.Name())); err != nil {
+			return fmt.Errorf("failed to link %s: %w", entry.Name(), err)
+		}
+	}
+
+	// A context without credentials uses the login no context owns
+	if !config.FileExists(filepath.Join(dir, config.CredentialsFile)) {
+		if login := m.sharedCredentials(chain[len(chain)-1]); login != "" {
+			if err := os.Symlink(login, filepath.Join(dir, config.CredentialsFile)); err != nil {
+				return fmt.Errorf("failed to link %s: %w", config.CredentialsFile, err)
+			}
+		}
+	}
+
+	// With CLAUDE_CONFIG_DIR set, Claude Code looks for ~/.claude.json in the
+	// configuration directory
+	statePath := filepath.Join(filepath.Dir(m.claudeDir), config.ClaudeStateFile)
+	if config.FileExists(statePath) && !config.FileExists(filepath.Join(dir, config.ClaudeStateFile)) {
+		if err := os.Symlink(statePath, filepath.Join(dir, config.ClaudeStateFile)); err != nil {
+			return fmt.Errorf("failed to link %s: %w", config.ClaudeStateFile, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package context
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/1outres/cldenv/internal/config"
+	"github.com/1outres/cldenv/internal/history"
+)
+
+// historyStore returns the history store of the cldenv directory
+func (m *Manager) historyStore() history.Store {
+	if m.history == nil {
+		m.history = history.Open(m.cldenvDir)
+	}
+	return m.history
+}
+
+// record commits the state of contexts to history. History is best effort,
+// so a failure only produces a warning.
+func (m *Manager) record(message string, contexts ...string) {
+	if err := m.historyStore().Commit(message, contexts...); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to record history: %v\n", err)
+	}
+}
+
+// RecordEdit records a change made to a file of a context outside of cldenv
+func (m *Manager) RecordEdit(name, file string) {
+	m.record(fmt.Sprintf("edit %s: %s", name, file), name)
+}
+
+// History returns the recorded revisions of a context, newest first. Removed
+// contexts keep their history, so the context does not have to exist.
+func (m *Manager) History(name string) ([]history.Revision, error) {
+	if !validNamePattern.MatchString(name) {
+		return nil, ErrContextNotFound
+	}
+	return m.historyStore().Log(name)
+}
+
+// FilesAt returns the files of a context at a revision, keyed by relative path
+func (m *Manager) FilesAt(name, rev string) (map[string][]byte, error) {
+	if !validNamePattern.MatchString(name) {
+		return nil, ErrContextNotFound
+	}
+	return m.historyStore().Files(name, rev)
+}
+
+// Files returns the current files of a context, keyed by relative path.
+// Sensitive files are left out, so they never reach exports, diffs or sync.
+func (m *Manager) Files(name string) (map[string][]byte, error) {
+	if !m.ContextExists(name) {
+		return nil, ErrContextNotFound
+	}
+
+	root := filepath.Join(m.cldenvDir, name)
+	files := make(map[string][]byte)
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil || config.IsSensitive(rel) {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = content
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read context files: %w", err)
+	}
+
+	return files, nil
+}
+
+// Rollback restores a context to a revision and records the rollback
+func (m *Manager) Rollback(name, rev string) error {
+	if err := ValidateContextName(name); err != nil {
+		return err
+	}
+
+	unlock, err := m.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// Save uncommitted changes so the rollback can itself be undone
+	if m.ContextExists(name) {
+		m.record(fmt.Sprintf("save %s before rollback", name), name)
+	}
+
+	// Credentials are not versioned and must survive the restore
+	sensitive := readSensitive(filepath.Join(m.cldenvDir, name))
+	if err := m.historyStore().Restore(name, rev); err != nil {
+		return err
+	}
+	if err := writeSensitive(filepath.Join(m.cldenvDir, name), sensitive); err != nil {
+		return err
+	}
+	m.record(fmt.Sprintf("rollback %s to %s", name, rev), name)
+
+	// Refresh the links if the restored context is in use
+	if m.getActiveContext() == name {
+		return m.SwitchContext(name)
+	}
+	return nil
+}
+
+// ParseRevision splits "<context>@<rev>" into its parts
+func ParseRevision(spec string) (string, string, bool) {
+	name, rev, ok := strings.Cut(spec, "@")
+	if !ok || name == "" || rev == "" {
+		return spec, "", false
+	}
+	return name, rev, true
+}
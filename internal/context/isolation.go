@@ -0,0 +1,269 @@
+package context
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/1outres/cldenv/internal/config"
+	"github.com/1outres/cldenv/pkg/symlink"
+)
+
+var ErrIsolationBroken = errors.New("~/.claude is not a link to ~/.cldenv/.home")
+
+// homeLinkPath returns the path of the .home pointer that ~/.claude links to
+// in isolation mode
+func (m *Manager) homeLinkPath() string {
+	return filepath.Join(m.cldenvDir, config.HomeLink)
+}
+
+// homeDir returns the Claude configuration directory owned by a context in
+// isolation mode
+func (m *Manager) homeDir(name string) string {
+	return filepath.Join(m.cldenvDir, config.HomesDir, name)
+}
+
+// activeHome returns the context whose home directory .home points at, or an
+// empty string if there is none
+func (m *Manager) activeHome() string {
+	target, err := os.Readlink(m.homeLinkPath())
+	if err != nil {
+		return ""
+	}
+	rel, err := filepath.Rel(filepath.Join(m.cldenvDir, config.HomesDir), target)
+	if err != nil || filepath.Dir(rel) != "." || rel == "." || rel == ".." {
+		return ""
+	}
+	return rel
+}
+
+// HomeDirs returns the contexts that own a home directory, and the directory
+func (m *Manager) HomeDirs() (map[string]string, error) {
+	entries, err := os.ReadDir(filepath.Join(m.cldenvDir, config.HomesDir))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read home directories: %w", err)
+	}
+
+	homes := make(map[string]string)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			homes[entry.Name()] = m.homeDir(entry.Name())
+		}
+	}
+	return homes, nil
+}
+
+// switchHome points ~/.claude at the home directory of a context by flipping
+// .home, creating the directory on first use. It does nothing outside
+// isolation mode. The returned function puts the previous home back.
+func (m *Manager) switchHome(name string) (func(), error) {
+	if !config.IsIsolated() {
+		return func() {}, nil
+	}
+
+	if target, err := os.Readlink(m.claudeDir); err != nil || target != m.homeLinkPath() {
+		return nil, fmt.Errorf("%w; run 'cldenv doctor'", ErrIsolationBroken)
+	}
+
+	home := m.homeDir(name)
+	if err := os.MkdirAll(home, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create home directory: %w", err)
+	}
+
+	state := captureLink(m.homeLinkPath())
+	if err := symlink.CreateSymlink(home, m.homeLinkPath()); err != nil {
+		return nil, fmt.Errorf("failed to switch home directory: %w", err)
+	}
+	return func() { state.restore() }, nil
+}
+
+// Isolate switches to isolation mode, where every context owns a whole Claude
+// configuration directory: credentials, session history, todos, plugins and
+// the managed artifacts. The current ~/.claude becomes the home directory of
+// the active context, and ~/.claude is replaced by a link to .home, which
+// 'cldenv use' re-points at the home of the context being switched to.
+func (m *Manager) Isolate() error {
+	unlock, err := m.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	cfg, err := config.LoadGlobalConfig()
+	if err != nil {
+		return err
+	}
+	if cfg.Isolated {
+		return nil
+	}
+
+	// Content that replaced a link would end up in a single home only
+	if drifts := m.DetectDrift(); len(drifts) > 0 {
+		return fmt.Errorf("%s was replaced by a regular file; run 'cldenv adopt' first", drifts[0].ClaudePath)
+	}
+
+	active := m.getActiveContext()
+	if active == "" || !m.ContextExists(active) {
+		active = config.GetDefaultContext()
+	}
+
+	home := m.homeDir(active)
+	if config.FileExists(home) {
+		return fmt.Errorf("%s already exists; move it away first", home)
+	}
+	if err := os.MkdirAll(filepath.Dir(home), 0700); err != nil {
+		return fmt.Errorf("failed to create home directories: %w", err)
+	}
+
+	moved := false
+	if info, err := os.Lstat(m.claudeDir); err == nil {
+		if !info.IsDir() {
+			return fmt.Errorf("%s is not a directory", m.claudeDir)
+		}
+		if err := os.Rename(m.claudeDir, home); err != nil {
+			return fmt.Errorf("failed to move %s, which must be on the same filesystem as %s: %w", m.claudeDir, m.cldenvDir, err)
+		}
+		moved = true
+	} else if err := os.Mkdir(home, 0700); err != nil {
+		return fmt.Errorf("failed to create home directory: %w", err)
+	}
+
+	rollback := func() {
+		os.Remove(m.claudeDir)
+		os.Remove(m.homeLinkPath())
+		if moved {
+			os.Rename(home, m.claudeDir)
+		} else {
+			os.Remove(home)
+		}
+	}
+
+	if err := symlink.CreateSymlink(home, m.homeLinkPath()); err != nil {
+		rollback()
+		return fmt.Errorf("failed to create home pointer: %w", err)
+	}
+	if err := os.Symlink(m.homeLinkPath(), m.claudeDir); err != nil {
+		rollback()
+		return fmt.Errorf("failed to link %s: %w", m.claudeDir, err)
+	}
+
+	cfg.Isolated = true
+	if err := config.SaveGlobalConfig(cfg); err != nil {
+		rollback()
+		return err
+	}
+
+	if err := m.SwitchContext(active); err != nil {
+		cfg.Isolated = false
+		if saveErr := config.SaveGlobalConfig(cfg); saveErr != nil {
+			return fmt.Errorf("failed to switch to context '%s': %w; isolation is still enabled: %v", active, err, saveErr)
+		}
+		rollback()
+		return fmt.Errorf("failed to switch to context '%s': %w", active, err)
+	}
+	return nil
+}
+
+// Unisolate returns to the default mode. The home directory of the active
+// context becomes ~/.claude again; the homes of other contexts are kept in
+// ~/.cldenv/.homes and returned so they can be merged or removed by hand.
+func (m *Manager) Unisolate() ([]string, error) {
+	unlock, err := m.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	cfg, err := config.LoadGlobalConfig()
+	if err != nil {
+		return nil, err
+	}
+	if !cfg.Isolated {
+		return nil, nil
+	}
+
+	active := m.activeHome()
+	if active == "" {
+		return nil, fmt.Errorf("%s does not point at a home directory; run 'cldenv doctor'", m.homeLinkPath())
+	}
+	if !config.IsSymlink(m.claudeDir) {
+		return nil, fmt.Errorf("%w; %s is not a symbolic link", ErrIsolationBroken, m.claudeDir)
+	}
+
+	if err := os.Remove(m.claudeDir); err != nil {
+		return nil, fmt.Errorf("failed to remove link %s: %w", m.claudeDir, err)
+	}
+	if err := os.Rename(m.homeDir(active), m.claudeDir); err != nil {
+		os.Symlink(m.homeLinkPath(), m.claudeDir)
+		return nil, fmt.Errorf("failed to move home directory of '%s': %w", active, err)
+	}
+	if err := os.Remove(m.homeLinkPath()); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to remove home pointer: %w", err)
+	}
+
+	cfg.Isolated = false
+	if err := config.SaveGlobalConfig(cfg); err != nil {
+		return nil, err
+	}
+
+	if m.ContextExists(active) {
+		if err := m.SwitchContext(active); err != nil {
+			return nil, fmt.Errorf("failed to switch to context '%s': %w", active, err)
+		}
+	}
+
+	homes, err := m.HomeDirs()
+	if err != nil {
+		return nil, err
+	}
+	var kept []string
+	for _, dir := range homes {
+		kept = append(kept, dir)
+	}
+	sort.Strings(kept)
+	return kept, nil
+}
+
+// diagnoseIsolation checks the ~/.claude and .home links in isolation mode
+func (m *Manager) diagnoseIsolation() []Finding {
+	if !config.IsIsolated() {
+		return nil
+	}
+
+	if target, err := os.Readlink(m.claudeDir); err != nil || target != m.homeLinkPath() {
+		return []Finding{{
+			Code:     CodeIsolationBroken,
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("isolation mode is on but %s does not link to %s", m.claudeDir, m.homeLinkPath()),
+			Path:     m.claudeDir,
+		}}
+	}
+
+	if m.homeMismatch() {
+		return []Finding{{
+			Code:     CodeHomeMismatch,
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("home directory pointer does not match the active context '%s'", m.getActiveContext()),
+			Path:     m.homeLinkPath(),
+			Fixable:  true,
+			fix:      m.relinkActive,
+		}}
+	}
+	return nil
+}
+
+// homeMismatch reports whether, in isolation mode, .home is missing or points
+// at the home of a context other than the one .current points at
+func (m *Manager) homeMismatch() bool {
+	if !config.IsIsolated() {
+		return false
+	}
+	if !symlink.IsValidSymlink(m.homeLinkPath()) {
+		return true
+	}
+
+	target, err := symlink.ReadSymlink(m.currentLinkPath())
+	return err == nil && m.activeHome() != m.extractContextName(target)
+}
@@ -0,0 +1,39 @@
+package context
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/1outres/cldenv/internal/config"
+)
+
+func TestIsolateRollsBackFailedSwitch(t *testing.T) {
+	m := newTestManager(t)
+	t.Setenv("CLDENV_PASSPHRASE", "test")
+	// A secret that does not exist makes the switch fail
+	settings := filepath.Join(m.cldenvDir, config.DefaultContext, config.SettingsFile)
+	if err := os.WriteFile(settings, []byte(`{"env": {"KEY": "${secret:MISSING}"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Isolate(); err == nil {
+		t.Fatal("Isolate() succeeded, want the switch to fail")
+	}
+
+	if config.IsIsolated() {
+		t.Error("isolation is still enabled")
+	}
+	info, err := os.Lstat(m.claudeDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.IsDir() {
+		t.Errorf("%s is not a directory again (mode %v)", m.claudeDir, info.Mode())
+	}
+	for _, path := range []string{m.homeLinkPath(), m.homeDir(config.DefaultContext)} {
+		if _, err := os.Lstat(path); !os.IsNotExist(err) {
+			t.Errorf("%s was left behind", path)
+		}
+	}
+}
@@ -0,0 +1,297 @@
+package context
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/1outres/cldenv/internal/config"
+	"github.com/1outres/cldenv/pkg/jsonmerge"
+)
+
+var (
+	ErrInheritanceCycle = errors.New("inheritance cycle")
+)
+
+// ResolveChain returns the inheritance chain of a context, root first
+func (m *Manager) ResolveChain(name string) ([]string, error) {
+	var chain []string
+	seen := make(map[string]bool)
+
+	for current := name; current != ""; {
+		if seen[current] {
+			return nil, fmt.Errorf("%w: %s -> %s", ErrInheritanceCycle, strings.Join(chain, " -> "), current)
+		}
+		seen[current] = true
+
+		if !m.ContextExists(current) {
+			if current == name {
+				return nil, ErrContextNotFound
+			}
+			return nil, fmt.Errorf("%w: parent '%s' of '%s'", ErrContextNotFound, current, chain[len(chain)-1])
+		}
+
+		meta, err := m.LoadMetadata(current)
+		if err != nil {
+			return nil, err
+		}
+
+		chain = append(chain, current)
+		current = meta.Extends
+	}
+
+	return reverse(chain), nil
+}
+
+// IsLayered reports whether a context inherits from a parent
+func (m *Manager) IsLayered(name string) bool {
+	meta, err := m.LoadMetadata(name)
+	return err == nil && meta.Extends != ""
+}
+
+// SetParent makes a context inherit from parent. An empty parent removes the inheritance.
+func (m *Manager) SetParent(name, parent string) error {
+	unlock, err := m.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if !m.ContextExists(name) {
+		return ErrContextNotFound
+	}
+
+	if parent != "" {
+		chain, err := m.ResolveChain(parent)
+		if err != nil {
+			return fmt.Errorf("invalid parent '%s': %w", parent, err)
+		}
+		for _, ancestor := range chain {
+			if ancestor == name {
+				return fmt.Errorf("%w: '%s' already inherits from '%s'", ErrInheritanceCycle, parent, name)
+			}
+		}
+	}
+
+	meta, err := m.LoadMetadata(name)
+	if err != nil {
+		return err
+	}
+
+	meta.Extends = parent
+	if err := m.SaveMetadata(name, meta); err != nil {
+		return err
+	}
+
+	if parent == "" {
+		m.record(fmt.Sprintf("remove parent of %s", name), name)
+	} else {
+		m.record(fmt.Sprintf("set parent of %s to %s", name, parent), name)
+	}
+	return nil
+}
+
+// materialize returns the directory ~/.claude should link to for a context.
+// Contexts without a parent are linked directly; layered contexts are
+// rendered into a fresh ~/.cldenv/.rendered/<name>/<generation> directory so
+// the copy currently in use is never modified.
+func (m *Manager) materialize(name string) (dir string, err error) {
+	chain, err := m.ResolveChain(name)
+	if err != nil {
+		return "", err
+	}
+
+	contextPath := filepath.Join(m.cldenvDir, name)
+	if len(chain) == 1 {
+		return contextPath, nil
+	}
+
+	renderedRoot := filepath.Join(m.cldenvDir, config.RenderedDir, name)
+	if err := config.CreateDir(renderedRoot); err != nil {
+		return "", fmt.Errorf("failed to create rendered context directory: %w", err)
+	}
+
+	renderedPath, err := os.MkdirTemp(renderedRoot, "r-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create rendered context directory: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			os.RemoveAll(renderedPath)
+		}
+	}()
+
+	if err := os.Chmod(renderedPath, 0755); err != nil {
+		return "", fmt.Errorf("failed to set rendered context permissions: %w", err)
+	}
+
+	if err := m.renderChain(chain, renderedPath); err != nil {
+		return "", err
+	}
+	return renderedPath, nil
+}
+
+// renderChain writes the artifacts of a layered context into dir
+func (m *Manager) renderChain(chain []string, dir string) error {
+	settings, err := m.renderSettings(chain)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, config.SettingsFile), settings, 0644); err != nil {
+		return fmt.Errorf("failed to write rendered settings.json: %w", err)
+	}
+
+	claudeFile, err := m.renderClaudeFile(chain)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, config.ClaudeFile), claudeFile, 0644); err != nil {
+		return fmt.Errorf("failed to write rendered CLAUDE.md: %w", err)
+	}
+
+	// Credentials are never inherited; a layered context only uses its own
+	credentials := filepath.Join(m.cldenvDir, chain[len(chain)-1], config.CredentialsFile)
+	if config.FileExists(credentials) {
+		if err := os.Symlink(credentials, filepath.Join(dir, config.CredentialsFile)); err != nil {
+			return fmt.Errorf("failed to link %s: %w", config.CredentialsFile, err)
+		}
+	}
+
+	// Directories are overlaid, with files from children replacing those of parents
+	for _, artifact := range config.Artifacts {
+		if !artifact.Dir {
+			continue
+		}
+		for _, ancestor := range chain {
+			src := filepath.Join(m.cldenvDir, ancestor, artifact.Name)
+			if !config.FileExists(src) {
+				continue
+			}
+			if err := config.CopyDir(src, filepath.Join(dir, artifact.Name)); err != nil {
+				return fmt.Errorf("failed to render %s: %w", artifact.DisplayName(), err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// pruneRendered removes rendered generations of a context other than keep
+func (m *Manager) pruneRendered(name, keep string) {
+	renderedRoot := filepath.Join(m.cldenvDir, config.RenderedDir, name)
+
+	entries, err := os.ReadDir(renderedRoot)
+	if err != nil {
+		return
+	}
+
+	for _, entry := range entries {
+		path := filepath.Join(renderedRoot, entry.Name())
+		if path != keep {
+			os.RemoveAll(path)
+		}
+	}
+
+	if keep == "" || filepath.Dir(keep) != renderedRoot {
+		os.Remove(renderedRoot)
+	}
+}
+
+// renderSettings deep merges settings.json along an inheritance chain
+func (m *Manager) renderSettings(chain []string) ([]byte, error) {
+	var merged any = map[string]any{}
+
+	for _, name := range chain {
+		data, err := os.ReadFile(filepath.Join(m.cldenvDir, name, config.SettingsFile))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to read settings.json of '%s': %w", name, err)
+		}
+		if len(bytes.TrimSpace(data)) == 0 {
+			continue
+		}
+
+		var settings any
+		if err := json.Unmarshal(data, &settings); err != nil {
+			return nil, fmt.Errorf("failed to parse settings.json of '%s': %w", name, err)
+		}
+
+		opts, err := m.mergeOptions(name)
+		if err != nil {
+			return nil, err
+		}
+
+		merged = jsonmerge.Merge(merged, settings, opts)
+	}
+
+	data, err := json.MarshalIndent(merged, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode merged settings.json: %w", err)
+	}
+
+	return append(data, '\n'), nil
+}
+
+// mergeOptions builds the merge options declared by a context
+func (m *Manager) mergeOptions(name string) (jsonmerge.Options, error) {
+	meta, err := m.LoadMetadata(name)
+	if err != nil {
+		return jsonmerge.Options{}, err
+	}
+
+	arrays, err := jsonmerge.ParseStrategy(meta.ArrayMerge)
+	if err != nil {
+		return jsonmerge.Options{}, fmt.Errorf("context '%s': %w", name, err)
+	}
+
+	opts := jsonmerge.Options{Arrays: arrays, Paths: make(map[string]jsonmerge.ArrayStrategy)}
+	for path, s := range meta.ArrayMergePaths {
+		strategy, err := jsonmerge.ParseStrategy(s)
+		if err != nil {
+			return jsonmerge.Options{}, fmt.Errorf("context '%s': %w", name, err)
+		}
+		opts.Paths[path] = strategy
+	}
+
+	return opts, nil
+}
+
+// renderClaudeFile concatenates CLAUDE.md along an inheritance chain, parents first
+func (m *Manager) renderClaudeFile(chain []string) ([]byte, error) {
+	var parts [][]byte
+
+	for _, name := range chain {
+		data, err := os.ReadFile(filepath.Join(m.cldenvDir, name, config.ClaudeFile))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to read CLAUDE.md of '%s': %w", name, err)
+		}
+
+		data = bytes.TrimRight(data, "\n")
+		if len(data) > 0 {
+			parts = append(parts, data)
+		}
+	}
+
+	if len(parts) == 0 {
+		return nil, nil
+	}
+
+	return append(bytes.Join(parts, []byte("\n\n")), '\n'), nil
+}
+
+func reverse(names []string) []string {
+	result := make([]string, len(names))
+	for i, name := range names {
+		result[len(names)-1-i] = name
+	}
+	return result
+}
@@ -0,0 +1,86 @@
+package context
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/1outres/cldenv/internal/config"
+)
+
+func TestRenderSettingsArrayStrategies(t *testing.T) {
+	tests := []struct {
+		name string
+		meta Metadata
+		want []any
+	}{
+		{"replace by default", Metadata{}, []any{"Bash"}},
+		{"append everywhere", Metadata{ArrayMerge: "append"}, []any{"Read", "Bash"}},
+		{"append for one path", Metadata{ArrayMergePaths: map[string]string{"permissions.allow": "append"}}, []any{"Read", "Bash"}},
+		{"path overrides default", Metadata{ArrayMerge: "append", ArrayMergePaths: map[string]string{"permissions.allow": "replace"}}, []any{"Bash"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestManager(t)
+			writeSettings(t, m, config.DefaultContext, `{"model": "opus", "permissions": {"allow": ["Read"]}}`)
+			if err := m.CreateContext("child", CreateOptions{Extends: config.DefaultContext}); err != nil {
+				t.Fatal(err)
+			}
+			writeSettings(t, m, "child", `{"permissions": {"allow": ["Bash"]}}`)
+			meta := tt.meta
+			meta.Extends = config.DefaultContext
+			if err := m.SaveMetadata("child", &meta); err != nil {
+				t.Fatal(err)
+			}
+
+			data, err := m.renderSettings([]string{config.DefaultContext, "child"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var settings struct {
+				Model       string `json:"model"`
+				Permissions struct {
+					Allow []any `json:"allow"`
+				} `json:"permissions"`
+			}
+			if err := json.Unmarshal(data, &settings); err != nil {
+				t.Fatal(err)
+			}
+			if settings.Model != "opus" {
+				t.Errorf("model = %q, want the parent's %q", settings.Model, "opus")
+			}
+			if !reflect.DeepEqual(settings.Permissions.Allow, tt.want) {
+				t.Errorf("permissions.allow = %v, want %v", settings.Permissions.Allow, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveChainCycle(t *testing.T) {
+	m := newTestManager(t)
+	for _, name := range []string{"a", "b"} {
+		if err := m.CreateContext(name, CreateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, parent := range map[string]string{"a": "b", "b": "a"} {
+		if err := m.SaveMetadata(name, &Metadata{Extends: parent}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := m.ResolveChain("a"); !errors.Is(err, ErrInheritanceCycle) {
+		t.Errorf("ResolveChain() error = %v, want %v", err, ErrInheritanceCycle)
+	}
+}
+
+// writeSettings replaces settings.json of a context
+func writeSettings(t *testing.T, m *Manager, name, settings string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(m.cldenvDir, name, config.SettingsFile), []byte(settings), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
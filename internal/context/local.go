@@ -0,0 +1,239 @@
+package context
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/1outres/cldenv/internal/config"
+)
+
+// LocalContext describes a context requested by a .cldenv-context file
+type LocalContext struct {
+	Name string `json:"name"`
+	File string `json:"file"`
+}
+
+// FindLocalContext walks up from dir looking for a .cldenv-context file.
+// It returns nil if no file is found.
+func FindLocalContext(dir string) (*LocalContext, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve directory: %w", err)
+	}
+
+	for {
+		file := filepath.Join(dir, config.LocalContextFile)
+		info, err := os.Stat(file)
+		if err == nil && !info.IsDir() {
+			name, err := readLocalContextFile(file)
+			if err != nil {
+				return nil, err
+			}
+			if name != "" {
+				return &LocalContext{Name: name, File: file}, nil
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+// readLocalContextFile returns the first non-empty, non-comment line of a .cldenv-context file
+func readLocalContextFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		return line, nil
+	}
+
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	return "", nil
+}
+
+// WriteLocalContext writes a .cldenv-context file requesting the given context in dir
+func WriteLocalContext(dir, name string) (string, error) {
+	if err := ValidateContextName(name); err != nil {
+		return "", err
+	}
+
+	file, err := filepath.Abs(filepath.Join(dir, config.LocalContextFile))
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve directory: %w", err)
+	}
+	if err := os.WriteFile(file, []byte(name+"\n"), 0644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", file, err)
+	}
+
+	// The registry is only used to follow renames, so failing to update it is not fatal
+	register := func(pins []string) []string {
+		if slices.Contains(pins, file) {
+			return pins
+		}
+		return append(pins, file)
+	}
+	if err := updatePins(register); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to register %s: %v\n", file, err)
+	}
+
+	return file, nil
+}
+
+// RemoveLocalContext removes the .cldenv-context file in dir if it exists
+func RemoveLocalContext(dir string) error {
+	file, err := filepath.Abs(filepath.Join(dir, config.LocalContextFile))
+	if err != nil {
+		return fmt.Errorf("failed to resolve directory: %w", err)
+	}
+	if err := config.RemoveFile(file); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", file, err)
+	}
+
+	unregister := func(pins []string) []string {
+		return slices.DeleteFunc(pins, func(pin string) bool { return pin == file })
+	}
+	if err := updatePins(unregister); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to unregister %s: %v\n", file, err)
+	}
+	return nil
+}
+
+// AutoSwitch switches to the context requested for dir if it differs from the
+// active one. It avoids loading all contexts so it is cheap enough to run on
+// every directory change. It returns the context switched to, or an empty
+// string if nothing was done. A .cldenv-context file that cannot be read or
+// names a missing context is ignored, since the hook must stay quiet; 'cldenv
+// local' reports those.
+func (m *Manager) AutoSwitch(dir string) (string, error) {
+	local, err := FindLocalContext(dir)
+	if err != nil || local == nil {
+		return "", nil
+	}
+
+	if m.getActiveContext() == local.Name || !m.ContextExists(local.Name) {
+		return "", nil
+	}
+
+	if err := m.SwitchContext(local.Name); err != nil {
+		return "", err
+	}
+
+	return local.Name, nil
+}
+
+// pinsPath returns the path of the registry of .cldenv-context files written by cldenv
+func pinsPath() (string, error) {
+	cldenvDir, err := config.GetCldenvDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cldenvDir, config.PinsFile), nil
+}
+
+// loadPins returns the registered .cldenv-context files, one path per line
+func loadPins() ([]string, error) {
+	path, err := pinsPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var pins []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			pins = append(pins, line)
+		}
+	}
+	return pins, nil
+}
+
+// updatePins rewrites the registry with the result of fn
+func updatePins(fn func([]string) []string) error {
+	pins, err := loadPins()
+	if err != nil {
+		return err
+	}
+	pins = fn(pins)
+
+	path, err := pinsPath()
+	if err != nil {
+		return err
+	}
+	if err := config.EnsureDir(path); err != nil {
+		return err
+	}
+
+	content := ""
+	if len(pins) > 0 {
+		content = strings.Join(pins, "\n") + "\n"
+	}
+	return os.WriteFile(path, []byte(content), 0644)
+}
+
+// renamePins points registered .cldenv-context files requesting oldName at
+// newName and drops files that no longer exist. It returns the updated files.
+func renamePins(oldName, newName string) ([]string, error) {
+	pins, err := loadPins()
+	if err != nil {
+		return nil, err
+	}
+
+	var updated, kept []string
+	for _, file := range pins {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		kept = append(kept, file)
+
+		// Replace only the line naming the context so comments survive
+		lines := strings.Split(string(data), "\n")
+		for i, line := range lines {
+			trimmed := strings.TrimSpace(line)
+			if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+				continue
+			}
+			if trimmed == oldName {
+				lines[i] = newName
+				if err := os.WriteFile(file, []byte(strings.Join(lines, "\n")), 0644); err != nil {
+					return updated, fmt.Errorf("failed to update %s: %w", file, err)
+				}
+				updated = append(updated, file)
+			}
+			break
+		}
+	}
+
+	if len(kept) != len(pins) {
+		if err := updatePins(func([]string) []string { return kept }); err != nil {
+			return updated, err
+		}
+	}
+	return updated, nil
+}
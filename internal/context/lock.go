@@ -0,0 +1,46 @@
+package context
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/1outres/cldenv/internal/config"
+	"github.com/1outres/cldenv/pkg/filelock"
+)
+
+// LockTimeout is how long mutating operations wait for another cldenv process
+var LockTimeout = 5 * time.Second
+
+// lock takes the inter-process lock guarding ~/.cldenv and returns a function
+// releasing it. Calls nest, so an operation holding the lock can call other
+// locking operations of the same manager.
+func (m *Manager) lock() (func(), error) {
+	if m.lockDepth > 0 {
+		m.lockDepth++
+		return m.unlock, nil
+	}
+
+	if err := config.CreateDir(m.cldenvDir); err != nil {
+		return nil, fmt.Errorf("failed to create cldenv directory: %w", err)
+	}
+
+	path := filepath.Join(m.cldenvDir, config.LockFile)
+	lock, err := filelock.Acquire(path, LockTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire %s: %w", path, err)
+	}
+
+	m.heldLock = lock
+	m.lockDepth = 1
+	return m.unlock, nil
+}
+
+// unlock releases one level of the lock taken by lock
+func (m *Manager) unlock() {
+	m.lockDepth--
+	if m.lockDepth == 0 {
+		m.heldLock.Release()
+		m.heldLock = nil
+	}
+}
@@ -5,9 +5,13 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/1outres/cldenv/internal/config"
+	"github.com/1outres/cldenv/internal/history"
+	"github.com/1outres/cldenv/pkg/filelock"
 	"github.com/1outres/cldenv/pkg/symlink"
 )
 
@@ -17,12 +21,23 @@ var (
 	ErrInvalidContextName   = errors.New("invalid context name")
 )
 
-// Context represents a cldenv context
+// Context represents a cldenv context. The JSON encoding is part of the
+// machine-readable output of the CLI, so fields must keep their names.
 type Context struct {
-	Name     string
-	Path     string
-	IsActive bool
-	Files    []string
+	Name     string   `json:"name"`
+	Path     string   `json:"path"`
+	IsActive bool     `json:"active"`
+	Files    []string `json:"files"`
+	// Present reports for every managed artifact whether the context carries it
+	Present     map[string]bool `json:"present"`
+	// Credentials reports whether the context carries its own login
+	Credentials bool            `json:"credentials"`
+	Extends     string          `json:"extends,omitempty"`
+	Description string          `json:"description,omitempty"`
+	Tags        []string        `json:"tags,omitempty"`
+	Owner       string          `json:"owner,omitempty"`
+	Created     time.Time       `json:"created,omitzero"`
+	LastUsed    time.Time       `json:"lastUsed,omitzero"`
 }
 
 // Manager manages cldenv contexts
@@ -30,6 +45,10 @@ type Manager struct {
 	claudeDir  string
 	cldenvDir  string
 	contexts   []Context
+	lockDepth  int
+	heldLock   *filelock.Lock
+	history    history.Store
+	pass       string
 }
 
 // NewManager creates a new context manager
@@ -63,11 +82,12 @@ func (m *Manager) LoadContexts() error {
 
 	m.contexts = nil
 	activeContext := m.getActiveContext()
+	lastUsed, _ := m.lastUsed()
 
 	for _, entry := range entries {
 		if entry.IsDir() {
-			// Skip .git directory
-			if entry.Name() == ".git" {
+			// Skip .git and other internal directories such as .rendered
+			if strings.HasPrefix(entry.Name(), ".") {
 				continue
 			}
 			
@@ -79,7 +99,21 @@ func (m *Manager) LoadContexts() error {
 				Path:     contextPath,
 				IsActive: entry.Name() == activeContext,
 				Files:    files,
+				Present:  make(map[string]bool),
 			}
+			for _, artifact := range config.Artifacts {
+				context.Present[artifact.DisplayName()] = slices.Contains(files, artifact.DisplayName())
+			}
+			context.Credentials = context.Present[config.CredentialsFile]
+
+			if meta, err := m.LoadMetadata(entry.Name()); err == nil {
+				context.Extends = meta.Extends
+				context.Description = meta.Description
+				context.Tags = meta.Tags
+				context.Owner = meta.Owner
+				context.Created = meta.Created
+			}
+			context.LastUsed = lastUsed[entry.Name()]
 			
 			m.contexts = append(m.contexts, context)
 		}
@@ -100,93 +134,160 @@ func (m *Manager) GetActiveContext() string {
 
 // getActiveContext determines which context is currently active
 func (m *Manager) getActiveContext() string {
-	claudeFilePath, err := config.GetClaudeFilePath()
-	if err != nil {
-		return ""
+	// The .current pointer is authoritative
+	currentPath := m.currentLinkPath()
+	if symlink.IsValidSymlink(currentPath) {
+		target, err := symlink.ReadSymlink(currentPath)
+		if err == nil {
+			return m.extractContextName(target)
+		}
 	}
 
-	settingsFilePath, err := config.GetSettingsFilePath()
-	if err != nil {
-		return ""
+	// In isolation mode the home directory names the context too
+	if config.IsIsolated() {
+		if name := m.activeHome(); name != "" {
+			return name
+		}
 	}
 
-	// Check if files are symlinks and point to a context
-	if symlink.IsValidSymlink(claudeFilePath) {
-		target, err := symlink.ReadSymlink(claudeFilePath)
-		if err == nil {
-			return m.extractContextName(target)
+	// Fall back to artifacts linked directly into a context by older versions.
+	// Links that disagree leave no single active context.
+	active := ""
+	for _, artifact := range config.Artifacts {
+		path, err := config.GetClaudeArtifactPath(artifact)
+		if err != nil {
+			return ""
 		}
-	}
 
-	if symlink.IsValidSymlink(settingsFilePath) {
-		target, err := symlink.ReadSymlink(settingsFilePath)
-		if err == nil {
-			return m.extractContextName(target)
+		if !symlink.IsValidSymlink(path) {
+			continue
+		}
+
+		target, err := symlink.ReadSymlink(path)
+		if err != nil {
+			continue
 		}
+
+		name := m.extractContextName(target)
+		if name == "" {
+			continue
+		}
+		if active != "" && name != active {
+			return ""
+		}
+		active = name
 	}
 
-	return ""
+	return active
 }
 
 // extractContextName extracts context name from a file path
 func (m *Manager) extractContextName(path string) string {
 	// Path should be like: ~/.cldenv/context-name/filename
+	// or ~/.cldenv/.rendered/context-name/filename for layered contexts
+	// or ~/.cldenv/.homes/context-name in isolation mode
 	rel, err := filepath.Rel(m.cldenvDir, path)
 	if err != nil {
 		return ""
 	}
 	
 	parts := strings.Split(rel, string(filepath.Separator))
-	if len(parts) >= 1 {
+	if len(parts) >= 2 && (parts[0] == config.RenderedDir || parts[0] == config.HomesDir) {
+		return parts[1]
+	}
+	if len(parts) >= 1 && parts[0] != ".." && !strings.HasPrefix(parts[0], ".") {
 		return parts[0]
 	}
 	
 	return ""
 }
 
-// getContextFiles returns the list of files in a context directory
+// getContextFiles returns the list of managed artifacts in a context directory
 func (m *Manager) getContextFiles(contextPath string) []string {
-	var files []string
+	files := []string{}
 	
-	claudeFile := filepath.Join(contextPath, config.ClaudeFile)
-	if config.FileExists(claudeFile) {
-		files = append(files, config.ClaudeFile)
-	}
-	
-	settingsFile := filepath.Join(contextPath, config.SettingsFile)
-	if config.FileExists(settingsFile) {
-		files = append(files, config.SettingsFile)
+	for _, artifact := range config.Artifacts {
+		if config.FileExists(filepath.Join(contextPath, artifact.Name)) {
+			files = append(files, artifact.DisplayName())
+		}
 	}
 	
 	return files
 }
 
-// CreateContext creates a new context
-func (m *Manager) CreateContext(name string) error {
+// CreateContext creates a new context with a valid settings.json and CLAUDE.md,
+// a clone of another context or the files of a template
+func (m *Manager) CreateContext(name string, opts CreateOptions) error {
 	if err := ValidateContextName(name); err != nil {
 		return err
 	}
+	for _, tag := range opts.Tags {
+		if err := ValidateTag(tag); err != nil {
+			return err
+		}
+	}
+
+	unlock, err := m.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
 	contextPath := filepath.Join(m.cldenvDir, name)
 	if config.FileExists(contextPath) {
 		return ErrContextAlreadyExists
 	}
 
-	if err := config.CreateDir(contextPath); err != nil {
+	if opts.Extends != "" && !m.ContextExists(opts.Extends) {
+		return fmt.Errorf("%w: parent '%s'", ErrContextNotFound, opts.Extends)
+	}
+
+	if err := m.scaffoldContext(name, opts); err != nil {
+		os.RemoveAll(contextPath)
 		return fmt.Errorf("failed to create context directory: %w", err)
 	}
 
+	meta, err := m.LoadMetadata(name)
+	if err != nil {
+		return err
+	}
+	if opts.Extends != "" {
+		meta.Extends = opts.Extends
+	}
+	if opts.Description != "" {
+		meta.Description = opts.Description
+	}
+	for _, tag := range opts.Tags {
+		if !meta.HasTag(tag) {
+			meta.Tags = append(meta.Tags, tag)
+		}
+	}
+	if opts.Owner != "" {
+		meta.Owner = opts.Owner
+	}
+	meta.Created = time.Now().UTC().Truncate(time.Second)
+	if err := m.SaveMetadata(name, meta); err != nil {
+		return err
+	}
+
+	m.record(fmt.Sprintf("create %s", name), name)
 	return nil
 }
 
 // RemoveContext removes a context
 func (m *Manager) RemoveContext(name string) error {
-	if name == config.DefaultContext {
+	if name == config.GetDefaultContext() {
 		return fmt.Errorf("cannot remove default context")
 	}
 
+	unlock, err := m.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	contextPath := filepath.Join(m.cldenvDir, name)
-	if !config.FileExists(contextPath) {
+	if !m.ContextExists(name) {
 		return ErrContextNotFound
 	}
 
@@ -195,49 +296,131 @@ func (m *Manager) RemoveContext(name string) error {
 		return fmt.Errorf("cannot remove active context")
 	}
 
+	// Don't remove if other contexts inherit from it
+	if children := m.childContexts(name); len(children) > 0 {
+		return fmt.Errorf("cannot remove context extended by %s", strings.Join(children, ", "))
+	}
+
 	if err := os.RemoveAll(contextPath); err != nil {
 		return fmt.Errorf("failed to remove context directory: %w", err)
 	}
 
+	if err := os.RemoveAll(filepath.Join(m.cldenvDir, config.RenderedDir, name)); err != nil {
+		return fmt.Errorf("failed to remove rendered context directory: %w", err)
+	}
+
+	if err := os.RemoveAll(m.homeDir(name)); err != nil {
+		return fmt.Errorf("failed to remove home directory: %w", err)
+	}
+	if err := removeResolved(name); err != nil {
+		return fmt.Errorf("failed to remove resolved settings: %w", err)
+	}
+	if err := os.RemoveAll(m.homeDir(name) + config.CredentialsFile); err != nil {
+		return fmt.Errorf("failed to remove stashed credentials: %w", err)
+	}
+	// A context created later under the same name has not been used yet
+	if err := m.updateLastUsed(func(used map[string]time.Time) { delete(used, name) }); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to update last use: %v\n", err)
+	}
+
+	m.record(fmt.Sprintf("remove %s", name), name)
+
 	return nil
 }
 
 // SwitchContext switches to a different context
 func (m *Manager) SwitchContext(name string) error {
+	unlock, err := m.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	contextPath := filepath.Join(m.cldenvDir, name)
-	if !config.FileExists(contextPath) {
+	if !m.ContextExists(name) {
 		return ErrContextNotFound
 	}
+	previous := m.getActiveContext()
 
-	claudeFilePath, err := config.GetClaudeFilePath()
+	// Layered contexts are linked to their rendered copy
+	contextPath, err = m.materialize(name)
 	if err != nil {
-		return fmt.Errorf("failed to get claude file path: %w", err)
+		return fmt.Errorf("failed to materialize context: %w", err)
 	}
 
-	settingsFilePath, err := config.GetSettingsFilePath()
+	// Settings referencing secrets are resolved into ~/.claude only
+	settings, err := m.resolvedSettings(name, contextPath)
 	if err != nil {
-		return fmt.Errorf("failed to get settings file path: %w", err)
+		return fmt.Errorf("failed to resolve secrets: %w", err)
 	}
 
-	// Create symlinks to the new context
-	contextClaudeFile := filepath.Join(contextPath, config.ClaudeFile)
-	contextSettingsFile := filepath.Join(contextPath, config.SettingsFile)
+	if err := m.checkCredentials(); err != nil {
+		return err
+	}
 
-	if err := symlink.CreateSymlink(contextClaudeFile, claudeFilePath); err != nil {
-		return fmt.Errorf("failed to create symlink for CLAUDE.md: %w", err)
+	// In isolation mode ~/.claude moves to the home of the context first, so
+	// the links below are created there
+	restoreHome, err := m.switchHome(name)
+	if err != nil {
+		return err
 	}
 
-	if err := symlink.CreateSymlink(contextSettingsFile, settingsFilePath); err != nil {
-		// Try to rollback the first symlink
-		symlink.RemoveSymlink(claudeFilePath)
-		return fmt.Errorf("failed to create symlink for settings.json: %w", err)
+	if err := m.switchTo(name, contextPath, settings); err != nil {
+		restoreHome()
+		return err
 	}
 
+	m.pruneRendered(name, contextPath)
+	m.touch(name)
+	// Re-rendering the active context is not a switch
+	if previous != name {
+		m.recordSwitch(previous, name)
+	}
 	return nil
 }
 
-// ContextExists checks if a context exists
+// ContextExists checks if a context exists. Internal entries such as .git
+// and .current live next to the contexts and are never one.
 func (m *Manager) ContextExists(name string) bool {
+	if !validNamePattern.MatchString(name) {
+		return false
+	}
 	contextPath := filepath.Join(m.cldenvDir, name)
 	return config.FileExists(contextPath)
+}
+
+// isManagedLink reports whether path is a symlink pointing into the cldenv directory
+func (m *Manager) isManagedLink(path string) bool {
+	if !config.IsSymlink(path) {
+		return false
+	}
+
+	target, err := symlink.ReadSymlink(path)
+	if err != nil {
+		return false
+	}
+
+	rel, err := filepath.Rel(m.cldenvDir, target)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// childContexts returns the names of contexts that directly extend the given context
+func (m *Manager) childContexts(name string) []string {
+	var children []string
+
+	entries, err := os.ReadDir(m.cldenvDir)
+	if err != nil {
+		return nil
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		if meta, err := m.LoadMetadata(entry.Name()); err == nil && meta.Extends == name {
+			children = append(children, entry.Name())
+		}
+	}
+
+	return children
 }
\ No newline at end of file
@@ -0,0 +1,64 @@
+package context
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newTestManager sets up cldenv in a temporary home directory, as on the
+// first run over an existing ~/.claude, and returns a manager for it
+func newTestManager(t *testing.T) *Manager {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_RUNTIME_DIR", filepath.Join(home, "run"))
+
+	claudeDir := filepath.Join(home, ".claude")
+	if err := os.MkdirAll(claudeDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(claudeDir, "settings.json"), []byte("{}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := MigrateToDefault(); err != nil {
+		t.Fatal(err)
+	}
+	if err := EnsureDefaultContext(); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := NewManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestRemoveRejectsInternalEntries(t *testing.T) {
+	m := newTestManager(t)
+	if err := m.CreateContext("work", CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	revisions, err := m.History("work")
+	if err != nil || len(revisions) == 0 {
+		t.Fatalf("History() = %v, %v; want recorded revisions", revisions, err)
+	}
+
+	for _, name := range []string{".git", ".current", "..", "work/.."} {
+		if err := m.RemoveContext(name); !errors.Is(err, ErrContextNotFound) {
+			t.Errorf("RemoveContext(%q) error = %v, want %v", name, err, ErrContextNotFound)
+		}
+		if _, err := m.RenameContext(name, "renamed"); !errors.Is(err, ErrContextNotFound) {
+			t.Errorf("RenameContext(%q) error = %v, want %v", name, err, ErrContextNotFound)
+		}
+		if _, err := m.History(name); !errors.Is(err, ErrContextNotFound) {
+			t.Errorf("History(%q) error = %v, want %v", name, err, ErrContextNotFound)
+		}
+	}
+
+	if after, err := m.History("work"); err != nil || len(after) != len(revisions) {
+		t.Errorf("history was lost: History() = %v, %v", after, err)
+	}
+}
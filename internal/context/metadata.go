@@ -0,0 +1,229 @@
+package context
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/1outres/cldenv/internal/config"
+)
+
+// Metadata holds the per-context settings stored in context.json
+type Metadata struct {
+	// Extends names the parent context this context inherits from
+	Extends string `json:"extends,omitempty"`
+	// ArrayMerge is the default array strategy used when merging settings.json
+	// onto the parent ("replace" or "append")
+	ArrayMerge string `json:"arrayMerge,omitempty"`
+	// ArrayMergePaths overrides the array strategy for specific key paths,
+	// e.g. {"permissions.allow": "append"}
+	ArrayMergePaths map[string]string `json:"arrayMergePaths,omitempty"`
+	// Local marks a machine-local context that is never synced
+	Local bool `json:"local,omitempty"`
+	// Description says what the context is for
+	Description string `json:"description,omitempty"`
+	// Tags group related contexts, e.g. by client
+	Tags []string `json:"tags,omitempty"`
+	// Owner is the person responsible for the context
+	Owner string `json:"owner,omitempty"`
+	// Created is when the context was created
+	Created time.Time `json:"created,omitzero"`
+}
+
+// LoadMetadata reads the metadata of a context. A missing context.json yields empty metadata.
+func (m *Manager) LoadMetadata(name string) (*Metadata, error) {
+	path := filepath.Join(m.cldenvDir, name, config.MetadataFile)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Metadata{}, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", config.MetadataFile, err)
+	}
+
+	var meta Metadata
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("failed to parse %s for context '%s': %w", config.MetadataFile, name, err)
+	}
+
+	return &meta, nil
+}
+
+// SaveMetadata writes the metadata of a context
+func (m *Manager) SaveMetadata(name string, meta *Metadata) error {
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", config.MetadataFile, err)
+	}
+
+	path := filepath.Join(m.cldenvDir, name, config.MetadataFile)
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", config.MetadataFile, err)
+	}
+
+	return nil
+}
+
+// HasTag reports whether the metadata carries a tag
+func (meta *Metadata) HasTag(tag string) bool {
+	return slices.Contains(meta.Tags, tag)
+}
+
+// ValidateTag checks that a tag uses the same characters as context names
+func ValidateTag(tag string) error {
+	if !validNamePattern.MatchString(tag) {
+		return fmt.Errorf("invalid tag '%s': tags can only contain letters, numbers, dashes, and underscores", tag)
+	}
+	return nil
+}
+
+// UpdateMetadata changes the metadata of a context and records the change
+func (m *Manager) UpdateMetadata(name string, update func(*Metadata) error) error {
+	unlock, err := m.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if !m.ContextExists(name) {
+		return ErrContextNotFound
+	}
+
+	meta, err := m.LoadMetadata(name)
+	if err != nil {
+		return err
+	}
+	if err := update(meta); err != nil {
+		return err
+	}
+	if err := m.SaveMetadata(name, meta); err != nil {
+		return err
+	}
+
+	m.record(fmt.Sprintf("describe %s", name), name)
+	return nil
+}
+
+// lastUsed returns when each context was last switched to. The times are
+// local to this machine and kept out of context.json, so switching does not
+// change the history of a context.
+func (m *Manager) lastUsed() (map[string]time.Time, error) {
+	used := make(map[string]time.Time)
+	data, err := os.ReadFile(filepath.Join(m.cldenvDir, config.LastUsedFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return used, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", config.LastUsedFile, err)
+	}
+	if err := json.Unmarshal(data, &used); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", config.LastUsedFile, err)
+	}
+	return used, nil
+}
+
+// updateLastUsed rewrites the last-used times with the changes made by fn
+func (m *Manager) updateLastUsed(fn func(map[string]time.Time)) error {
+	if !config.FileExists(filepath.Join(m.cldenvDir, config.LastUsedFile)) {
+		if err := m.migrateLastUsed(); err != nil {
+			return err
+		}
+	}
+
+	used, err := m.lastUsed()
+	if err != nil {
+		used = make(map[string]time.Time)
+	}
+	fn(used)
+
+	data, err := json.MarshalIndent(used, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", config.LastUsedFile, err)
+	}
+	return os.WriteFile(filepath.Join(m.cldenvDir, config.LastUsedFile), append(data, '\n'), 0644)
+}
+
+// MigrateLastUsed moves the last-used times older versions kept in
+// context.json to the local state. It only does something once.
+func MigrateLastUsed() error {
+	manager, err := NewManager()
+	if err != nil {
+		return err
+	}
+	if config.FileExists(filepath.Join(manager.cldenvDir, config.LastUsedFile)) {
+		return nil
+	}
+	return manager.migrateLastUsed()
+}
+
+// migrateLastUsed moves the lastUsed keys of context.json files to the
+// last-used times and records the contexts it changed
+func (m *Manager) migrateLastUsed() error {
+	unlock, err := m.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	entries, err := os.ReadDir(m.cldenvDir)
+	if err != nil {
+		return fmt.Errorf("failed to read contexts directory: %w", err)
+	}
+
+	used := make(map[string]time.Time)
+	var migrated []string
+	for _, entry := range entries {
+		if !entry.IsDir() || !m.ContextExists(entry.Name()) {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(m.cldenvDir, entry.Name(), config.MetadataFile))
+		if err != nil {
+			continue
+		}
+		var legacy struct {
+			LastUsed *time.Time `json:"lastUsed"`
+		}
+		if json.Unmarshal(data, &legacy) != nil || legacy.LastUsed == nil {
+			continue
+		}
+
+		// Saving drops the key, which Metadata no longer has
+		meta, err := m.LoadMetadata(entry.Name())
+		if err != nil {
+			return err
+		}
+		if err := m.SaveMetadata(entry.Name(), meta); err != nil {
+			return err
+		}
+		used[entry.Name()] = *legacy.LastUsed
+		migrated = append(migrated, entry.Name())
+	}
+
+	data, err := json.MarshalIndent(used, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", config.LastUsedFile, err)
+	}
+	if err := os.WriteFile(filepath.Join(m.cldenvDir, config.LastUsedFile), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", config.LastUsedFile, err)
+	}
+
+	if len(migrated) > 0 {
+		m.record("move last use out of context.json", migrated...)
+	}
+	return nil
+}
+
+// touch records that a context was switched to. The timestamp is informational,
+// so failing to save it only produces a warning.
+func (m *Manager) touch(name string) {
+	err := m.updateLastUsed(func(used map[string]time.Time) {
+		used[name] = time.Now().UTC().Truncate(time.Second)
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to update last use of '%s': %v\n", name, err)
+	}
+}
@@ -0,0 +1,87 @@
+package context
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/1outres/cldenv/internal/config"
+)
+
+func TestSwitchKeepsContextJSON(t *testing.T) {
+	m := newTestManager(t)
+	if err := m.CreateContext("work", CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(m.cldenvDir, "work", config.MetadataFile)
+	before, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.SwitchContext("work"); err != nil {
+		t.Fatal(err)
+	}
+
+	after, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(after) != string(before) {
+		t.Errorf("switching changed context.json:\n%s", after)
+	}
+
+	if err := m.LoadContexts(); err != nil {
+		t.Fatal(err)
+	}
+	for _, ctx := range m.GetContexts() {
+		if ctx.Name == "work" && ctx.LastUsed.IsZero() {
+			t.Error("last use of 'work' was not recorded")
+		}
+	}
+}
+
+func TestMigrateLastUsed(t *testing.T) {
+	m := newTestManager(t)
+	if err := m.CreateContext("work", CreateOptions{Description: "client work"}); err != nil {
+		t.Fatal(err)
+	}
+	// As written by older versions
+	path := filepath.Join(m.cldenvDir, "work", config.MetadataFile)
+	legacy := []byte(`{"description": "client work", "lastUsed": "2025-01-02T03:04:05Z"}`)
+	if err := os.WriteFile(path, legacy, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(m.cldenvDir, config.LastUsedFile)); err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+
+	if err := MigrateLastUsed(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "lastUsed") {
+		t.Errorf("context.json still records the last use:\n%s", data)
+	}
+	meta, err := m.LoadMetadata("work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Description != "client work" {
+		t.Errorf("description = %q, want %q", meta.Description, "client work")
+	}
+
+	used, err := m.lastUsed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC); !used["work"].Equal(want) {
+		t.Errorf("last use of 'work' = %v, want %v", used["work"], want)
+	}
+}
@@ -2,33 +2,38 @@ package context
 
 import (
 	"fmt"
+	"path/filepath"
 
 	"github.com/1outres/cldenv/internal/config"
-	"github.com/1outres/cldenv/pkg/symlink"
 )
 
-// IsFirstRun checks if this is the first run of cldenv
+// IsFirstRun checks if this is the first run of cldenv.
+// Regular files in ~/.claude while a context is active are drift to adopt
+// into that context, not a first run.
 func IsFirstRun() bool {
-	claudeFilePath, err := config.GetClaudeFilePath()
+	manager, err := NewManager()
 	if err != nil {
 		return false
 	}
 
-	settingsFilePath, err := config.GetSettingsFilePath()
-	if err != nil {
+	if manager.getActiveContext() != "" {
 		return false
 	}
 
-	// Check if either file exists and is NOT a symlink
-	claudeExists := config.FileExists(claudeFilePath)
-	settingsExists := config.FileExists(settingsFilePath)
-
-	if claudeExists && !config.IsSymlink(claudeFilePath) {
-		return true
-	}
+	// Check if any managed artifact exists and is NOT a symlink. Logins are
+	// only owned by contexts on request.
+	for _, artifact := range config.Artifacts {
+		if artifact.Sensitive {
+			continue
+		}
+		path, err := config.GetClaudeArtifactPath(artifact)
+		if err != nil {
+			return false
+		}
 
-	if settingsExists && !config.IsSymlink(settingsFilePath) {
-		return true
+		if config.FileExists(path) && !config.IsSymlink(path) {
+			return true
+		}
 	}
 
 	return false
@@ -36,18 +41,25 @@ func IsFirstRun() bool {
 
 // MigrateToDefault migrates existing files to the default context
 func MigrateToDefault() error {
-	claudeFilePath, err := config.GetClaudeFilePath()
+	manager, err := NewManager()
 	if err != nil {
-		return fmt.Errorf("failed to get claude file path: %w", err)
+		return err
 	}
 
-	settingsFilePath, err := config.GetSettingsFilePath()
+	unlock, err := manager.lock()
 	if err != nil {
-		return fmt.Errorf("failed to get settings file path: %w", err)
+		return err
+	}
+	defer unlock()
+
+	// Another process may have migrated while we waited for the lock
+	if !IsFirstRun() {
+		return nil
 	}
 
 	// Create default context directory
-	defaultContextPath, err := config.GetContextDir(config.DefaultContext)
+	defaultContext := config.GetDefaultContext()
+	defaultContextPath, err := config.GetContextDir(defaultContext)
 	if err != nil {
 		return fmt.Errorf("failed to get default context path: %w", err)
 	}
@@ -56,38 +68,35 @@ func MigrateToDefault() error {
 		return fmt.Errorf("failed to create default context directory: %w", err)
 	}
 
-	// Migrate CLAUDE.md if it exists and is not a symlink
-	if config.FileExists(claudeFilePath) && !config.IsSymlink(claudeFilePath) {
-		defaultClaudeFile, err := config.GetContextFilePath(config.DefaultContext, config.ClaudeFile)
-		if err != nil {
-			return fmt.Errorf("failed to get default claude file path: %w", err)
+	for _, artifact := range config.Artifacts {
+		if artifact.Sensitive {
+			continue
 		}
-
-		if err := config.MoveFile(claudeFilePath, defaultClaudeFile); err != nil {
-			return fmt.Errorf("failed to move CLAUDE.md to default context: %w", err)
+		claudePath, err := config.GetClaudeArtifactPath(artifact)
+		if err != nil {
+			return fmt.Errorf("failed to get path for %s: %w", artifact.Name, err)
 		}
 
-		// Create symlink
-		if err := symlink.CreateSymlink(defaultClaudeFile, claudeFilePath); err != nil {
-			return fmt.Errorf("failed to create symlink for CLAUDE.md: %w", err)
+		// Migrate the artifact if it exists and is not a symlink
+		if !config.FileExists(claudePath) || config.IsSymlink(claudePath) {
+			continue
 		}
-	}
 
-	// Migrate settings.json if it exists and is not a symlink
-	if config.FileExists(settingsFilePath) && !config.IsSymlink(settingsFilePath) {
-		defaultSettingsFile, err := config.GetContextFilePath(config.DefaultContext, config.SettingsFile)
+		defaultPath, err := config.GetContextFilePath(defaultContext, artifact.Name)
 		if err != nil {
-			return fmt.Errorf("failed to get default settings file path: %w", err)
+			return fmt.Errorf("failed to get default path for %s: %w", artifact.Name, err)
 		}
 
-		if err := config.MoveFile(settingsFilePath, defaultSettingsFile); err != nil {
-			return fmt.Errorf("failed to move settings.json to default context: %w", err)
+		if err := config.MoveFile(claudePath, defaultPath); err != nil {
+			return fmt.Errorf("failed to move %s to default context: %w", artifact.DisplayName(), err)
 		}
+	}
 
-		// Create symlink
-		if err := symlink.CreateSymlink(defaultSettingsFile, settingsFilePath); err != nil {
-			return fmt.Errorf("failed to create symlink for settings.json: %w", err)
-		}
+	manager.record(fmt.Sprintf("migrate existing files into %s", defaultContext), defaultContext)
+
+	// Link the migrated files through the context pointer
+	if err := manager.SwitchContext(defaultContext); err != nil {
+		return fmt.Errorf("failed to switch to default context: %w", err)
 	}
 
 	return nil
@@ -95,75 +104,93 @@ func MigrateToDefault() error {
 
 // EnsureDefaultContext ensures the default context exists with proper symlinks
 func EnsureDefaultContext() error {
-	// Check if default context directory exists
-	defaultContextPath, err := config.GetContextDir(config.DefaultContext)
+	manager, err := NewManager()
 	if err != nil {
-		return fmt.Errorf("failed to get default context path: %w", err)
+		return err
 	}
 
-	if err := config.CreateDir(defaultContextPath); err != nil {
-		return fmt.Errorf("failed to create default context directory: %w", err)
+	// Only take the lock when something has to be repaired, so read-only
+	// commands don't block each other
+	if !manager.needsDefaultRepair() {
+		return nil
 	}
 
-	claudeFilePath, err := config.GetClaudeFilePath()
+	unlock, err := manager.lock()
 	if err != nil {
-		return fmt.Errorf("failed to get claude file path: %w", err)
+		return err
 	}
+	defer unlock()
 
-	settingsFilePath, err := config.GetSettingsFilePath()
+	// Check if default context directory exists
+	defaultContext := config.GetDefaultContext()
+	defaultContextPath, err := config.GetContextDir(defaultContext)
 	if err != nil {
-		return fmt.Errorf("failed to get settings file path: %w", err)
+		return fmt.Errorf("failed to get default context path: %w", err)
 	}
 
-	defaultClaudeFile, err := config.GetContextFilePath(config.DefaultContext, config.ClaudeFile)
-	if err != nil {
-		return fmt.Errorf("failed to get default claude file path: %w", err)
+	if err := config.CreateDir(defaultContextPath); err != nil {
+		return fmt.Errorf("failed to create default context directory: %w", err)
 	}
 
-	defaultSettingsFile, err := config.GetContextFilePath(config.DefaultContext, config.SettingsFile)
-	if err != nil {
-		return fmt.Errorf("failed to get default settings file path: %w", err)
-	}
+	created := false
+	for _, artifact := range config.Artifacts {
+		if !artifact.Required {
+			continue
+		}
 
-	// Create empty files in default context if they don't exist
-	if !config.FileExists(defaultClaudeFile) {
-		if err := config.EnsureDir(defaultClaudeFile); err != nil {
-			return fmt.Errorf("failed to ensure directory for CLAUDE.md: %w", err)
+		defaultPath, err := config.GetContextFilePath(defaultContext, artifact.Name)
+		if err != nil {
+			return fmt.Errorf("failed to get default path for %s: %w", artifact.Name, err)
 		}
-		// Create empty file
-		if err := config.CopyFile("/dev/null", defaultClaudeFile); err != nil {
-			// If /dev/null doesn't work, create empty file manually
-			if err := config.CreateDir(defaultContextPath); err != nil {
-				return fmt.Errorf("failed to create default context directory: %w", err)
+
+		// Create the artifact in default context if it doesn't exist
+		if !config.FileExists(defaultPath) {
+			if err := config.EnsureDir(defaultPath); err != nil {
+				return fmt.Errorf("failed to ensure directory for %s: %w", artifact.Name, err)
+			}
+			if err := createEmptyArtifact(defaultPath, artifact); err != nil {
+				return fmt.Errorf("failed to create %s in default context: %w", artifact.DisplayName(), err)
 			}
+			created = true
 		}
 	}
 
-	if !config.FileExists(defaultSettingsFile) {
-		if err := config.EnsureDir(defaultSettingsFile); err != nil {
-			return fmt.Errorf("failed to ensure directory for settings.json: %w", err)
-		}
-		// Create empty JSON file
-		if err := config.CopyFile("/dev/null", defaultSettingsFile); err != nil {
-			// If /dev/null doesn't work, create empty file manually
-			if err := config.CreateDir(defaultContextPath); err != nil {
-				return fmt.Errorf("failed to create default context directory: %w", err)
-			}
-		}
+	if created {
+		manager.record(fmt.Sprintf("initialize %s", defaultContext), defaultContext)
 	}
 
-	// Create symlinks if they don't exist or are broken
-	if !symlink.IsValidSymlink(claudeFilePath) {
-		if err := symlink.CreateSymlink(defaultClaudeFile, claudeFilePath); err != nil {
-			return fmt.Errorf("failed to create symlink for CLAUDE.md: %w", err)
-		}
+	// Create links if they don't exist, are broken or predate the context pointer
+	if !manager.needsRelink() {
+		return nil
 	}
 
-	if !symlink.IsValidSymlink(settingsFilePath) {
-		if err := symlink.CreateSymlink(defaultSettingsFile, settingsFilePath); err != nil {
-			return fmt.Errorf("failed to create symlink for settings.json: %w", err)
+	// Keep a context activated by direct links from an older version
+	activeContext := manager.getActiveContext()
+	if activeContext != "" && activeContext != defaultContext {
+		if err := manager.SwitchContext(activeContext); err == nil {
+			return nil
 		}
 	}
 
+	if err := manager.SwitchContext(defaultContext); err != nil {
+		return fmt.Errorf("failed to link default context: %w", err)
+	}
+
 	return nil
-}
\ No newline at end of file
+}
+
+// needsDefaultRepair reports whether the default context is missing files or
+// the links in ~/.claude need to be recreated
+func (m *Manager) needsDefaultRepair() bool {
+	defaultContext := config.GetDefaultContext()
+	for _, artifact := range config.Artifacts {
+		if !artifact.Required {
+			continue
+		}
+		if !config.FileExists(filepath.Join(m.cldenvDir, defaultContext, artifact.Name)) {
+			return true
+		}
+	}
+
+	return m.needsRelink()
+}
@@ -0,0 +1,180 @@
+package context
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/1outres/cldenv/internal/config"
+	"github.com/1outres/cldenv/pkg/symlink"
+)
+
+var ErrDefaultContext = errors.New("cannot rename the default context")
+
+// RenameResult describes what a rename updated besides the context directory
+type RenameResult struct {
+	// Children are contexts that now extend the new name
+	Children []string `json:"children,omitempty"`
+	// Pins are .cldenv-context files that now request the new name
+	Pins []string `json:"pins,omitempty"`
+	// Relinked reports whether the links in ~/.claude were re-pointed
+	Relinked bool `json:"relinked"`
+}
+
+// RenameContext renames a context, updating the contexts that extend it, the
+// directory pins requesting it and, when it is in use, the links in ~/.claude
+func (m *Manager) RenameContext(oldName, newName string) (*RenameResult, error) {
+	if err := ValidateContextName(newName); err != nil {
+		return nil, err
+	}
+
+	unlock, err := m.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if !m.ContextExists(oldName) {
+		return nil, ErrContextNotFound
+	}
+	if m.ContextExists(newName) {
+		return nil, ErrContextAlreadyExists
+	}
+	if oldName == config.GetDefaultContext() {
+		return nil, fmt.Errorf("%w; make another context the default with 'cldenv set-default <context>' first", ErrDefaultContext)
+	}
+
+	// Work out which contexts in use depend on the renamed one before moving it
+	active := m.getActiveContext()
+	activeChain, _ := m.ResolveChain(active)
+	relink := active != "" && slices.Contains(activeChain, oldName)
+	if active == oldName {
+		active = newName
+	}
+
+	children := m.childContexts(oldName)
+
+	// The pointers follow the directories right away, so the active context
+	// never reads as none and no switch is recorded for the rename
+	oldPath, newPath := filepath.Join(m.cldenvDir, oldName), filepath.Join(m.cldenvDir, newName)
+	if err := os.Rename(oldPath, newPath); err != nil {
+		return nil, fmt.Errorf("failed to rename context directory: %w", err)
+	}
+	if err := repoint(m.currentLinkPath(), oldPath, newPath); err != nil {
+		os.Rename(newPath, oldPath)
+		return nil, fmt.Errorf("failed to re-point %s: %w", m.currentLinkPath(), err)
+	}
+	oldHome, newHome := m.homeDir(oldName), m.homeDir(newName)
+	movedHome := false
+	if config.FileExists(oldHome) {
+		if err := os.Rename(oldHome, newHome); err != nil {
+			repoint(m.currentLinkPath(), newPath, oldPath)
+			os.Rename(newPath, oldPath)
+			return nil, fmt.Errorf("failed to rename home directory: %w", err)
+		}
+		repoint(m.homeLinkPath(), oldHome, newHome)
+		movedHome = true
+	}
+	if stash := oldHome + config.CredentialsFile; config.FileExists(stash) {
+		if err := os.Rename(stash, newHome+config.CredentialsFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to rename stashed credentials: %v\n", err)
+		}
+	}
+
+	result := &RenameResult{Relinked: relink}
+	for _, child := range children {
+		meta, err := m.LoadMetadata(child)
+		if err != nil {
+			return nil, err
+		}
+		meta.Extends = newName
+		if err := m.SaveMetadata(child, meta); err != nil {
+			return nil, err
+		}
+		result.Children = append(result.Children, child)
+	}
+
+	if relink {
+		if err := m.SwitchContext(active); err != nil {
+			// Put the context back so the links that still point at it keep working
+			if movedHome {
+				os.Rename(newHome, oldHome)
+				repoint(m.homeLinkPath(), newHome, oldHome)
+			}
+			if rerr := os.Rename(newPath, oldPath); rerr == nil {
+				repoint(m.currentLinkPath(), newPath, oldPath)
+				for _, child := range result.Children {
+					if meta, err := m.LoadMetadata(child); err == nil {
+						meta.Extends = oldName
+						m.SaveMetadata(child, meta)
+					}
+				}
+			}
+			return nil, fmt.Errorf("failed to re-point links: %w", err)
+		}
+	}
+
+	if err := os.RemoveAll(filepath.Join(m.cldenvDir, config.RenderedDir, oldName)); err != nil {
+		return nil, fmt.Errorf("failed to remove rendered context directory: %w", err)
+	}
+	if err := removeResolved(oldName); err != nil {
+		return nil, fmt.Errorf("failed to remove resolved settings: %w", err)
+	}
+
+	pins, err := renamePins(oldName, newName)
+	result.Pins = pins
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to update directory pins: %v\n", err)
+	}
+	if err := m.renameSwitches(oldName, newName); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to update switch log: %v\n", err)
+	}
+	err = m.updateLastUsed(func(used map[string]time.Time) {
+		if t, ok := used[oldName]; ok {
+			used[newName] = t
+			delete(used, oldName)
+		}
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to update last use: %v\n", err)
+	}
+
+	m.record(fmt.Sprintf("rename %s to %s", oldName, newName), append([]string{oldName, newName}, children...)...)
+	return result, nil
+}
+
+// repoint atomically re-points link at newTarget if it points at oldTarget
+func repoint(link, oldTarget, newTarget string) error {
+	if target, err := os.Readlink(link); err != nil || target != oldTarget {
+		return nil
+	}
+	return symlink.CreateSymlink(newTarget, link)
+}
+
+// SetDefaultContext makes another context the default, the one created on
+// first run and linked when nothing else is active
+func (m *Manager) SetDefaultContext(name string) error {
+	unlock, err := m.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if !m.ContextExists(name) {
+		return ErrContextNotFound
+	}
+
+	cfg, err := config.LoadGlobalConfig()
+	if err != nil {
+		return err
+	}
+
+	cfg.DefaultContext = name
+	if name == config.DefaultContext {
+		cfg.DefaultContext = ""
+	}
+	return config.SaveGlobalConfig(cfg)
+}
@@ -0,0 +1,162 @@
+package context
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/1outres/cldenv/internal/config"
+)
+
+// resolvedSettings returns settings.json of a materialized context directory
+// with its secret placeholders resolved, or nil if it references no secrets.
+// The result is only ever written to the runtime directory, never to
+// ~/.cldenv.
+func (m *Manager) resolvedSettings(name, dir string) ([]byte, error) {
+	settings, err := os.ReadFile(filepath.Join(dir, config.SettingsFile))
+	if err != nil || !usesSecrets(settings) {
+		return nil, nil
+	}
+
+	values, err := m.resolveSecrets(name)
+	if err != nil {
+		return nil, err
+	}
+	return substituteSecrets(settings, values)
+}
+
+// referencesSecrets reports whether the settings.json at path references secrets
+func (m *Manager) referencesSecrets(path string) bool {
+	settings, err := os.ReadFile(path)
+	return err == nil && usesSecrets(settings)
+}
+
+// runtimeSettingsPath returns where the resolved settings of a context are
+// written; ~/.claude/settings.json links there while the context is active
+func runtimeSettingsPath(name string) string {
+	return filepath.Join(config.GetRuntimeDir(), name, config.SettingsFile)
+}
+
+// privateDir creates dir readable only by the user, refusing one that is a
+// link or belongs to someone else
+func privateDir(dir string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+	// Only the owner may change the mode
+	if err := os.Chmod(dir, 0700); err != nil {
+		return fmt.Errorf("%s is not private: %w", dir, err)
+	}
+	return nil
+}
+
+// writeResolvedSettings writes resolved settings to path in the runtime
+// directory, readable only by the user, along with their checksum so edits
+// made through a link can be told apart
+func (m *Manager) writeResolvedSettings(path string, settings []byte) error {
+	if err := privateDir(config.GetRuntimeDir()); err != nil {
+		return err
+	}
+	if err := privateDir(filepath.Dir(path)); err != nil {
+		return err
+	}
+
+	tmp := fmt.Sprintf("%s.tmp-%d", path, os.Getpid())
+	if err := os.WriteFile(tmp, settings, 0600); err != nil {
+		return fmt.Errorf("failed to write resolved settings.json: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to install resolved settings.json: %w", err)
+	}
+	if err := os.WriteFile(path+".sha256", []byte(checksum(settings)), 0600); err != nil {
+		return fmt.Errorf("failed to write checksum of resolved settings.json: %w", err)
+	}
+	return nil
+}
+
+// checksum identifies resolved settings without revealing them
+func checksum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// resolvedTarget returns the resolved settings path links to, if it links
+// into the runtime directory
+func resolvedTarget(path string) (string, bool) {
+	target, err := os.Readlink(path)
+	if err != nil || filepath.Base(target) != config.SettingsFile {
+		return "", false
+	}
+	rel, err := filepath.Rel(config.GetRuntimeDir(), target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return target, true
+}
+
+// resolvedEdited reports whether path links to resolved settings that were
+// changed since cldenv wrote them. Such edits are drift and have to be adopted.
+func resolvedEdited(path string) bool {
+	target, ok := resolvedTarget(path)
+	if !ok {
+		return false
+	}
+	content, err := os.ReadFile(target)
+	if err != nil {
+		return false
+	}
+	want, err := os.ReadFile(target + ".sha256")
+	return err != nil || string(want) != checksum(content)
+}
+
+// removeResolved removes the resolved settings of a context
+func removeResolved(name string) error {
+	return os.RemoveAll(filepath.Dir(runtimeSettingsPath(name)))
+}
+
+// DriftContent returns the content of a drifted file, with the values of
+// secrets the context references turned back into placeholders, so it can be
+// shown or adopted without revealing them
+func (m *Manager) DriftContent(drift Drift) ([]byte, error) {
+	content, err := os.ReadFile(drift.ClaudePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", drift.ClaudePath, err)
+	}
+	if drift.Artifact.Name != config.SettingsFile || !m.referencesSecrets(drift.ContextPath) {
+		return content, nil
+	}
+
+	values, err := m.resolveSecrets(drift.Context)
+	if err != nil {
+		return nil, err
+	}
+	return unsubstituteSecrets(content, values), nil
+}
+
+// adoptResolvedSettings adopts an edited copy of resolved settings, turning
+// the secret values in it back into placeholders
+func (m *Manager) adoptResolvedSettings(drift Drift) error {
+	content, err := m.DriftContent(drift)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(drift.ContextPath, content, 0644); err != nil {
+		return fmt.Errorf("failed to write settings.json of '%s': %w", drift.Context, err)
+	}
+	if err := os.Remove(drift.ClaudePath); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", drift.ClaudePath, err)
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package context
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/1outres/cldenv/internal/config"
+)
+
+// builtinTemplates holds the templates shipped with cldenv, one directory each
+//
+//go:embed templates
+var builtinTemplates embed.FS
+
+// basicTemplate scaffolds contexts created without --from or --template
+const basicTemplate = "basic"
+
+var ErrTemplateNotFound = errors.New("template not found")
+
+// CreateOptions configures a new context. Without From or Files the context is
+// scaffolded from the basic template.
+type CreateOptions struct {
+	// From is a context to clone
+	From string
+	// Files are the initial files keyed by relative path, e.g. from RenderTemplate
+	Files map[string][]byte
+	// Extends is the parent context to inherit from
+	Extends string
+	// Description, Tags and Owner are stored in the context metadata
+	Description string
+	Tags        []string
+	Owner       string
+}
+
+// Templates returns the names of the built-in and user templates
+func (m *Manager) Templates() []string {
+	names := make(map[string]bool)
+
+	entries, _ := fs.ReadDir(builtinTemplates, "templates")
+	for _, entry := range entries {
+		names[entry.Name()] = true
+	}
+
+	entries, _ = os.ReadDir(filepath.Join(m.cldenvDir, config.TemplatesDir))
+	for _, entry := range entries {
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			names[entry.Name()] = true
+		}
+	}
+
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// RenderTemplate returns the files of a template for a new context, with
+// variables such as {{.context}} filled in. User templates in
+// ~/.cldenv/.templates take precedence over built-in ones.
+func (m *Manager) RenderTemplate(tmpl, contextName string, vars map[string]string) (map[string][]byte, error) {
+	if !validNamePattern.MatchString(tmpl) {
+		return nil, fmt.Errorf("%w: '%s'", ErrTemplateNotFound, tmpl)
+	}
+
+	var source fs.FS
+	userDir := filepath.Join(m.cldenvDir, config.TemplatesDir, tmpl)
+	if info, err := os.Stat(userDir); err == nil && info.IsDir() {
+		source = os.DirFS(userDir)
+	} else if sub, err := fs.Sub(builtinTemplates, "templates/"+tmpl); err == nil {
+		if _, err := fs.Stat(sub, "."); err == nil {
+			source = sub
+		}
+	}
+	if source == nil {
+		return nil, fmt.Errorf("%w: '%s' (available: %s)", ErrTemplateNotFound, tmpl, strings.Join(m.Templates(), ", "))
+	}
+
+	data := templateData(contextName, vars)
+	files := make(map[string][]byte)
+	err := fs.WalkDir(source, ".", func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.Type().IsRegular() {
+			return err
+		}
+
+		content, err := fs.ReadFile(source, path)
+		if err != nil {
+			return err
+		}
+
+		t, err := template.New(path).Option("missingkey=error").Parse(string(content))
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		var out bytes.Buffer
+		if err := t.Execute(&out, data); err != nil {
+			return fmt.Errorf("failed to render %s: %w", path, err)
+		}
+
+		files[path] = out.Bytes()
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("template '%s': %w", tmpl, err)
+	}
+
+	return files, nil
+}
+
+// templateData returns the variables available to templates
+func templateData(contextName string, vars map[string]string) map[string]string {
+	data := map[string]string{
+		"context": contextName,
+		"date":    time.Now().Format("2006-01-02"),
+		"user":    os.Getenv("USER"),
+	}
+	if current, err := user.Current(); err == nil {
+		data["user"] = current.Username
+	}
+
+	for k, v := range vars {
+		data[k] = v
+	}
+	return data
+}
+
+// scaffoldContext fills a new context directory according to opts
+func (m *Manager) scaffoldContext(name string, opts CreateOptions) error {
+	contextPath := filepath.Join(m.cldenvDir, name)
+
+	if opts.From != "" {
+		if !m.ContextExists(opts.From) {
+			return fmt.Errorf("%w: '%s'", ErrContextNotFound, opts.From)
+		}
+		return config.CopyDir(filepath.Join(m.cldenvDir, opts.From), contextPath)
+	}
+
+	files := opts.Files
+	if files == nil {
+		rendered, err := m.RenderTemplate(basicTemplate, name, nil)
+		if err != nil {
+			return err
+		}
+		files = rendered
+
+		// Layered contexts inherit their instructions instead of starting with a heading
+		if opts.Extends != "" {
+			delete(files, config.ClaudeFile)
+		}
+	}
+
+	if err := config.CreateDir(contextPath); err != nil {
+		return err
+	}
+	return m.writeContextFiles(name, files)
+}
@@ -0,0 +1,404 @@
+package context
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/1outres/cldenv/internal/config"
+	"github.com/1outres/cldenv/pkg/passphrase"
+	"golang.org/x/crypto/argon2"
+)
+
+var (
+	ErrSecretNotFound     = errors.New("secret not found")
+	ErrInvalidSecretName  = errors.New("invalid secret name")
+	ErrWrongPassphrase    = errors.New("wrong passphrase")
+	ErrPassphraseMismatch = errors.New("passphrases do not match")
+)
+
+const (
+	// The key is derived from the passphrase with Argon2id, using the
+	// parameters RFC 9106 recommends for memory-constrained environments
+	secretsKDF     = "argon2id"
+	secretsTime    = 3
+	secretsMemory  = 64 * 1024 // KiB
+	secretsThreads = 4
+	secretsKeyLen  = 32
+	secretsSaltLen = 16
+
+	// passphraseEnv can hold the passphrase for scripts and 'cldenv watch'
+	passphraseEnv = "CLDENV_PASSPHRASE"
+)
+
+var (
+	secretNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	// secretPlaceholder is a reference to a secret in settings.json
+	secretPlaceholder = regexp.MustCompile(`\$\{secret:([A-Za-z_][A-Za-z0-9_]*)\}`)
+)
+
+// secretStore is the encrypted secrets file of a context. Every value is
+// sealed with AES-256-GCM under a key derived from the passphrase, with the
+// secret name as additional data so values cannot be swapped. The cost
+// parameters are stored with the salt, so stores written with other costs
+// can still be opened.
+type secretStore struct {
+	KDF     string            `json:"kdf"`
+	Salt    []byte            `json:"salt"`
+	Time    uint32            `json:"time"`
+	Memory  uint32            `json:"memory"`
+	Threads uint8             `json:"threads"`
+	Secrets map[string][]byte `json:"secrets"`
+}
+
+// ValidateSecretName checks that a secret can be referenced from settings.json
+func ValidateSecretName(name string) error {
+	if !secretNamePattern.MatchString(name) {
+		return fmt.Errorf("%w '%s': use letters, digits and underscores, not starting with a digit", ErrInvalidSecretName, name)
+	}
+	return nil
+}
+
+// secretsPath returns the path of the secrets file of a context
+func (m *Manager) secretsPath(name string) string {
+	return filepath.Join(m.cldenvDir, name, config.SecretsFile)
+}
+
+// loadSecrets reads the secrets file of a context, or an empty store
+func (m *Manager) loadSecrets(name string) (*secretStore, error) {
+	data, err := os.ReadFile(m.secretsPath(name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &secretStore{Secrets: make(map[string][]byte)}, nil
+		}
+		return nil, fmt.Errorf("failed to read secrets of '%s': %w", name, err)
+	}
+
+	var store secretStore
+	if err := json.Unmarshal(data, &store); err != nil {
+		return nil, fmt.Errorf("failed to parse secrets of '%s': %w", name, err)
+	}
+	if len(store.Secrets) > 0 && (store.KDF != secretsKDF || store.Time == 0 || store.Memory == 0 || store.Threads == 0) {
+		return nil, fmt.Errorf("secrets of '%s' use unsupported key derivation '%s'", name, store.KDF)
+	}
+	if store.Secrets == nil {
+		store.Secrets = make(map[string][]byte)
+	}
+	return &store, nil
+}
+
+// saveSecrets writes the secrets file of a context, removing it once empty
+func (m *Manager) saveSecrets(name string, store *secretStore) error {
+	path := m.secretsPath(name)
+	if len(store.Secrets) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove secrets of '%s': %w", name, err)
+		}
+		return nil
+	}
+
+	data, err := json.MarshalIndent(store, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode secrets: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write secrets of '%s': %w", name, err)
+	}
+	return nil
+}
+
+// aead returns the cipher for a store and passphrase
+func (s *secretStore) aead(pass string) (cipher.AEAD, error) {
+	key := argon2.IDKey([]byte(pass), s.Salt, s.Time, s.Memory, s.Threads, secretsKeyLen)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// open decrypts every secret of the store
+func (s *secretStore) open(pass string) (map[string]string, error) {
+	values := make(map[string]string)
+	if len(s.Secrets) == 0 {
+		return values, nil
+	}
+
+	aead, err := s.aead(pass)
+	if err != nil {
+		return nil, err
+	}
+	for name, sealed := range s.Secrets {
+		if len(sealed) < aead.NonceSize() {
+			return nil, fmt.Errorf("secret %s is corrupt", name)
+		}
+		nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+		plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(name))
+		if err != nil {
+			return nil, ErrWrongPassphrase
+		}
+		values[name] = string(plaintext)
+	}
+	return values, nil
+}
+
+// seal encrypts every value into the store, replacing its contents. A new
+// salt is drawn each time, so the whole file changes with every update.
+func (s *secretStore) seal(pass string, values map[string]string) error {
+	s.KDF = secretsKDF
+	s.Time = secretsTime
+	s.Memory = secretsMemory
+	s.Threads = secretsThreads
+	s.Salt = make([]byte, secretsSaltLen)
+	if _, err := rand.Read(s.Salt); err != nil {
+		return err
+	}
+
+	aead, err := s.aead(pass)
+	if err != nil {
+		return err
+	}
+	s.Secrets = make(map[string][]byte)
+	for name, value := range values {
+		nonce := make([]byte, aead.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return err
+		}
+		s.Secrets[name] = aead.Seal(nonce, nonce, []byte(value), []byte(name))
+	}
+	return nil
+}
+
+// passphrase returns the passphrase protecting the secrets, from
+// $CLDENV_PASSPHRASE or the terminal. It is asked once per process. With
+// confirm, a passphrase typed for a new store is asked twice.
+func (m *Manager) passphrase(confirm bool) (string, error) {
+	if m.pass != "" {
+		return m.pass, nil
+	}
+	if pass := os.Getenv(passphraseEnv); pass != "" {
+		m.pass = pass
+		return pass, nil
+	}
+
+	pass, err := passphrase.Read("cldenv passphrase: ")
+	if err != nil {
+		return "", fmt.Errorf("%w; set %s", err, passphraseEnv)
+	}
+	if confirm {
+		again, err := passphrase.Read("Repeat passphrase: ")
+		if err != nil {
+			return "", err
+		}
+		if again != pass {
+			return "", ErrPassphraseMismatch
+		}
+	}
+	if pass == "" {
+		return "", fmt.Errorf("the passphrase must not be empty")
+	}
+
+	m.pass = pass
+	return pass, nil
+}
+
+// SecretNames returns the names of the secrets stored in a context, which
+// can be listed without the passphrase
+func (m *Manager) SecretNames(name string) ([]string, error) {
+	if !m.ContextExists(name) {
+		return nil, ErrContextNotFound
+	}
+
+	store, err := m.loadSecrets(name)
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for secret := range store.Secrets {
+		names = append(names, secret)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// SetSecret stores a secret in a context, encrypted
+func (m *Manager) SetSecret(name, secret, value string) error {
+	if err := ValidateSecretName(secret); err != nil {
+		return err
+	}
+	return m.updateSecrets(name, fmt.Sprintf("set secret %s of %s", secret, name), func(values map[string]string) error {
+		values[secret] = value
+		return nil
+	})
+}
+
+// RemoveSecret deletes a secret from a context. A secret still referenced by
+// the settings of the context or of contexts extending it is kept.
+func (m *Manager) RemoveSecret(name, secret string) error {
+	return m.updateSecrets(name, fmt.Sprintf("remove secret %s of %s", secret, name), func(values map[string]string) error {
+		if _, ok := values[secret]; !ok {
+			return fmt.Errorf("%w: '%s' in context '%s'", ErrSecretNotFound, secret, name)
+		}
+		if users := m.secretUsers(name, secret); len(users) > 0 {
+			return fmt.Errorf("secret %s is referenced by settings.json of '%s'", secret, strings.Join(users, "', '"))
+		}
+		delete(values, secret)
+		return nil
+	})
+}
+
+// secretUsers returns the context and the contexts extending it whose own
+// settings.json references a secret
+func (m *Manager) secretUsers(name, secret string) []string {
+	var users []string
+	placeholder := []byte("${secret:" + secret + "}")
+	settings, err := os.ReadFile(filepath.Join(m.cldenvDir, name, config.SettingsFile))
+	if err == nil && bytes.Contains(settings, placeholder) {
+		users = append(users, name)
+	}
+	for _, child := range m.childContexts(name) {
+		users = append(users, m.secretUsers(child, secret)...)
+	}
+	return users
+}
+
+// updateSecrets decrypts the secrets of a context, applies fn and stores them
+// again, refreshing ~/.claude if the active context uses them
+func (m *Manager) updateSecrets(name, message string, fn func(map[string]string) error) error {
+	unlock, err := m.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if !m.ContextExists(name) {
+		return ErrContextNotFound
+	}
+
+	store, err := m.loadSecrets(name)
+	if err != nil {
+		return err
+	}
+	pass, err := m.passphrase(len(store.Secrets) == 0)
+	if err != nil {
+		return err
+	}
+	values, err := store.open(pass)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(values); err != nil {
+		return err
+	}
+	if err := store.seal(pass, values); err != nil {
+		return fmt.Errorf("failed to encrypt secrets: %w", err)
+	}
+	if err := m.saveSecrets(name, store); err != nil {
+		return err
+	}
+	m.record(message, name)
+
+	active := m.getActiveContext()
+	if chain, err := m.ResolveChain(active); err == nil && slices.Contains(chain, name) {
+		return m.SwitchContext(active)
+	}
+	return nil
+}
+
+// resolveSecrets decrypts the secrets available to a context: its own and
+// those of its parents, children overriding parents
+func (m *Manager) resolveSecrets(name string) (map[string]string, error) {
+	chain, err := m.ResolveChain(name)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string)
+	for _, ancestor := range chain {
+		store, err := m.loadSecrets(ancestor)
+		if err != nil {
+			return nil, err
+		}
+		if len(store.Secrets) == 0 {
+			continue
+		}
+		pass, err := m.passphrase(false)
+		if err != nil {
+			return nil, err
+		}
+		own, err := store.open(pass)
+		if err != nil {
+			return nil, fmt.Errorf("secrets of '%s': %w", ancestor, err)
+		}
+		for secret, value := range own {
+			values[secret] = value
+		}
+	}
+	return values, nil
+}
+
+// usesSecrets reports whether settings reference secrets
+func usesSecrets(settings []byte) bool {
+	return secretPlaceholder.Match(settings)
+}
+
+// substituteSecrets replaces the placeholders in settings.json with the
+// values of the secrets, escaped for use inside JSON strings
+func substituteSecrets(settings []byte, values map[string]string) ([]byte, error) {
+	var missing []string
+	result := secretPlaceholder.ReplaceAllFunc(settings, func(placeholder []byte) []byte {
+		secret := string(secretPlaceholder.FindSubmatch(placeholder)[1])
+		value, ok := values[secret]
+		if !ok {
+			missing = append(missing, secret)
+			return placeholder
+		}
+		return jsonStringContent(value)
+	})
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: settings.json references %s", ErrSecretNotFound, strings.Join(missing, ", "))
+	}
+	return result, nil
+}
+
+// unsubstituteSecrets turns the values of secrets in settings.json back into
+// placeholders, so settings edited in ~/.claude can be adopted without
+// writing secrets into the context
+func unsubstituteSecrets(settings []byte, values map[string]string) []byte {
+	// Longer values first, in case one contains another
+	secrets := make([]string, 0, len(values))
+	for secret := range values {
+		if values[secret] != "" {
+			secrets = append(secrets, secret)
+		}
+	}
+	sort.Slice(secrets, func(i, j int) bool {
+		return len(values[secrets[i]]) > len(values[secrets[j]])
+	})
+
+	for _, secret := range secrets {
+		settings = bytes.ReplaceAll(settings, jsonStringContent(values[secret]), []byte("${secret:"+secret+"}"))
+	}
+	return settings
+}
+
+// jsonStringContent returns s escaped for JSON, without the quotes
+func jsonStringContent(s string) []byte {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(s)
+	quoted := bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+	return quoted[1 : len(quoted)-1]
+}
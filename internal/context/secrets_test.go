@@ -0,0 +1,232 @@
+package context
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/1outres/cldenv/internal/config"
+)
+
+func TestSealOpen(t *testing.T) {
+	tests := []struct {
+		name   string
+		values map[string]string
+	}{
+		{"empty", map[string]string{}},
+		{"one", map[string]string{"API_KEY": "sk-123"}},
+		{"several", map[string]string{"A": "", "B": "with \"quotes\" and \\", "C": "ünïcode\n"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var store secretStore
+			if err := store.seal("pass", tt.values); err != nil {
+				t.Fatal(err)
+			}
+			for name, sealed := range store.Secrets {
+				if value := tt.values[name]; value != "" && bytes.Contains(sealed, []byte(value)) {
+					t.Errorf("secret %s is stored in plaintext", name)
+				}
+			}
+
+			got, err := store.open("pass")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !maps.Equal(got, tt.values) {
+				t.Errorf("open() = %v, want %v", got, tt.values)
+			}
+		})
+	}
+}
+
+func TestOpenRejectsTampering(t *testing.T) {
+	tests := []struct {
+		name   string
+		pass   string
+		tamper func(*secretStore)
+	}{
+		{"wrong passphrase", "other", func(*secretStore) {}},
+		{"swapped ciphertexts", "pass", func(s *secretStore) {
+			s.Secrets["A"], s.Secrets["B"] = s.Secrets["B"], s.Secrets["A"]
+		}},
+		{"changed salt", "pass", func(s *secretStore) {
+			s.Salt[0] ^= 1
+		}},
+		{"changed ciphertext", "pass", func(s *secretStore) {
+			s.Secrets["A"][len(s.Secrets["A"])-1] ^= 1
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var store secretStore
+			if err := store.seal("pass", map[string]string{"A": "first", "B": "second"}); err != nil {
+				t.Fatal(err)
+			}
+			tt.tamper(&store)
+
+			if _, err := store.open(tt.pass); !errors.Is(err, ErrWrongPassphrase) {
+				t.Errorf("open() error = %v, want %v", err, ErrWrongPassphrase)
+			}
+		})
+	}
+}
+
+func TestSubstituteSecrets(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings string
+		values   map[string]string
+		want     string
+	}{
+		{
+			name:     "plain",
+			settings: `{"env": {"KEY": "${secret:KEY}"}}`,
+			values:   map[string]string{"KEY": "sk-123"},
+			want:     "sk-123",
+		},
+		{
+			name:     "quote and backslash",
+			settings: `{"env": {"KEY": "${secret:KEY}"}}`,
+			values:   map[string]string{"KEY": `a"b\c`},
+			want:     `a"b\c`,
+		},
+		{
+			name:     "newline and markup",
+			settings: `{"env": {"KEY": "${secret:KEY}"}}`,
+			values:   map[string]string{"KEY": "<a>\n&"},
+			want:     "<a>\n&",
+		},
+		{
+			name:     "inside a longer string",
+			settings: `{"env": {"KEY": "Bearer ${secret:KEY}"}}`,
+			values:   map[string]string{"KEY": `x"y`},
+			want:     `Bearer x"y`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolved, err := substituteSecrets([]byte(tt.settings), tt.values)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var settings struct {
+				Env map[string]string `json:"env"`
+			}
+			if err := json.Unmarshal(resolved, &settings); err != nil {
+				t.Fatalf("resolved settings are not valid JSON: %v\n%s", err, resolved)
+			}
+			if settings.Env["KEY"] != tt.want {
+				t.Errorf("KEY = %q, want %q", settings.Env["KEY"], tt.want)
+			}
+
+			if back := unsubstituteSecrets(resolved, tt.values); string(back) != tt.settings {
+				t.Errorf("unsubstituteSecrets() = %s, want %s", back, tt.settings)
+			}
+		})
+	}
+
+	if _, err := substituteSecrets([]byte(`"${secret:MISSING}"`), nil); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("missing secret: error = %v, want %v", err, ErrSecretNotFound)
+	}
+}
+
+func TestUnsubstituteSecrets(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings string
+		values   map[string]string
+		want     string
+	}{
+		{
+			name:     "overlapping values",
+			settings: `{"a": "abc", "b": "abcdef"}`,
+			values:   map[string]string{"SHORT": "abc", "LONG": "abcdef"},
+			want:     `{"a": "${secret:SHORT}", "b": "${secret:LONG}"}`,
+		},
+		{
+			name:     "escaped value",
+			settings: `{"a": "x\"y\\z"}`,
+			values:   map[string]string{"KEY": `x"y\z`},
+			want:     `{"a": "${secret:KEY}"}`,
+		},
+		{
+			name:     "empty value is left alone",
+			settings: `{"a": ""}`,
+			values:   map[string]string{"EMPTY": ""},
+			want:     `{"a": ""}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unsubstituteSecrets([]byte(tt.settings), tt.values); string(got) != tt.want {
+				t.Errorf("unsubstituteSecrets() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSwitchKeepsSecretsOutOfCldenvDir(t *testing.T) {
+	m := newTestManager(t)
+	t.Setenv(passphraseEnv, "test passphrase")
+	const value = "sk-plaintext-0123456789"
+
+	if err := m.SetSecret(config.DefaultContext, "API_KEY", value); err != nil {
+		t.Fatal(err)
+	}
+	settings := filepath.Join(m.cldenvDir, config.DefaultContext, config.SettingsFile)
+	if err := os.WriteFile(settings, []byte(`{"env": {"ANTHROPIC_API_KEY": "${secret:API_KEY}"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m.RecordEdit(config.DefaultContext, config.SettingsFile)
+	if err := m.SwitchContext(config.DefaultContext); err != nil {
+		t.Fatal(err)
+	}
+
+	// Claude Code sees the value through the link
+	resolved, err := os.ReadFile(filepath.Join(m.claudeDir, config.SettingsFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(resolved, []byte(value)) {
+		t.Fatalf("~/.claude/settings.json lacks the secret:\n%s", resolved)
+	}
+
+	err = filepath.WalkDir(m.cldenvDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.Type().IsRegular() {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if bytes.Contains(content, []byte(value)) {
+			t.Errorf("%s contains the secret", path)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	revisions, err := m.History(config.DefaultContext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, revision := range revisions {
+		files, err := m.FilesAt(config.DefaultContext, revision.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for path, content := range files {
+			if bytes.Contains(content, []byte(value)) {
+				t.Errorf("%s at %s contains the secret", path, revision.ID)
+			}
+		}
+	}
+}
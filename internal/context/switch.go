@@ -0,0 +1,189 @@
+package context
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/1outres/cldenv/internal/config"
+	"github.com/1outres/cldenv/pkg/symlink"
+)
+
+// linkState records a path before a switch so it can be restored on failure
+type linkState struct {
+	path   string
+	target string
+	isLink bool
+}
+
+// captureLink records the current state of path
+func captureLink(path string) linkState {
+	state := linkState{path: path}
+	if target, err := os.Readlink(path); err == nil {
+		state.target = target
+		state.isLink = true
+	}
+	return state
+}
+
+// restore puts path back to the recorded state. Only symlinks are touched;
+// a path that was not a symlink before the switch is never replaced by one.
+func (s linkState) restore() error {
+	if s.isLink {
+		return symlink.CreateSymlink(s.target, s.path)
+	}
+	if config.IsSymlink(s.path) {
+		return symlink.RemoveSymlink(s.path)
+	}
+	return nil
+}
+
+// currentLinkPath returns the path of the .current pointer
+func (m *Manager) currentLinkPath() string {
+	return filepath.Join(m.cldenvDir, config.CurrentLink)
+}
+
+// switchTo points cldenv at dir, a context or rendered context directory.
+//
+// Every artifact in ~/.claude links to ~/.cldenv/.current/<artifact> and
+// .current links to the context, so renaming a single symlink over .current
+// flips the whole context at once. Any failure restores the previous links.
+//
+// When settings is not nil, settings.json of context name references secrets:
+// the resolved settings are written to the runtime directory and
+// ~/.claude/settings.json links there instead, so they never reach ~/.cldenv.
+func (m *Manager) switchTo(name, dir string, settings []byte) (err error) {
+	currentPath := m.currentLinkPath()
+	states := []linkState{captureLink(currentPath)}
+
+	// Credentials are swapped along with the links
+	unstash, err := m.stashCredentials(dir)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err == nil {
+			return
+		}
+		for i := len(states) - 1; i >= 0; i-- {
+			states[i].restore()
+		}
+		unstash()
+	}()
+
+	// Link every artifact the new context carries through the pointer
+	for _, artifact := range config.Artifacts {
+		claudePath, err := config.GetClaudeArtifactPath(artifact)
+		if err != nil {
+			return fmt.Errorf("failed to get path for %s: %w", artifact.Name, err)
+		}
+
+		if !artifact.Required && !config.FileExists(filepath.Join(dir, artifact.Name)) {
+			continue
+		}
+
+		// Never replace something the user manages outside of cldenv, or
+		// content a tool wrote over one of our links
+		if info, err := os.Lstat(claudePath); err == nil {
+			if info.Mode()&os.ModeSymlink == 0 {
+				return fmt.Errorf("%s was replaced by a regular %s; run 'cldenv adopt' to keep its changes", claudePath, kindOf(info))
+			}
+			if resolvedEdited(claudePath) {
+				return fmt.Errorf("%s was edited; run 'cldenv adopt' to keep its changes", claudePath)
+			}
+			if !artifact.Required && !m.isManagedLink(claudePath) {
+				return fmt.Errorf("%s exists and is not managed by cldenv", claudePath)
+			}
+		}
+
+		if err := config.EnsureDir(claudePath); err != nil {
+			return fmt.Errorf("failed to create claude directory: %w", err)
+		}
+
+		want := filepath.Join(currentPath, artifact.Name)
+		if artifact.Name == config.SettingsFile && settings != nil {
+			want = runtimeSettingsPath(name)
+			if err := m.writeResolvedSettings(want, settings); err != nil {
+				return err
+			}
+		}
+		if target, err := os.Readlink(claudePath); err == nil && target == want {
+			continue
+		}
+
+		states = append(states, captureLink(claudePath))
+		if err := symlink.CreateSymlink(want, claudePath); err != nil {
+			return fmt.Errorf("failed to create symlink for %s: %w", artifact.DisplayName(), err)
+		}
+	}
+
+	// Flip the pointer; this single rename switches the whole context
+	if err := symlink.CreateSymlink(dir, currentPath); err != nil {
+		return fmt.Errorf("failed to switch context pointer: %w", err)
+	}
+
+	// Drop links to artifacts the new context doesn't carry
+	for _, artifact := range config.Artifacts {
+		if artifact.Required || config.FileExists(filepath.Join(dir, artifact.Name)) {
+			continue
+		}
+
+		claudePath, err := config.GetClaudeArtifactPath(artifact)
+		if err != nil {
+			return fmt.Errorf("failed to get path for %s: %w", artifact.Name, err)
+		}
+
+		if m.isManagedLink(claudePath) {
+			states = append(states, captureLink(claudePath))
+			if err := symlink.RemoveSymlink(claudePath); err != nil {
+				return fmt.Errorf("failed to remove symlink for %s: %w", artifact.DisplayName(), err)
+			}
+		}
+	}
+
+	m.unstashCredentials(dir)
+	return nil
+}
+
+// needsRelink reports whether the pointer or the link of any artifact the
+// active context carries is missing, broken or not routed through the pointer
+func (m *Manager) needsRelink() bool {
+	currentPath := m.currentLinkPath()
+	if !symlink.IsValidSymlink(currentPath) || m.homeMismatch() {
+		return true
+	}
+
+	for _, artifact := range config.Artifacts {
+		if !artifact.Required && !config.FileExists(filepath.Join(currentPath, artifact.Name)) {
+			continue
+		}
+
+		claudePath, err := config.GetClaudeArtifactPath(artifact)
+		if err != nil {
+			return true
+		}
+
+		// Regular files are drift for 'cldenv adopt', never overwritten here
+		if info, err := os.Lstat(claudePath); err == nil && info.Mode()&os.ModeSymlink == 0 {
+			continue
+		}
+
+		// Resolved settings link outside ~/.cldenv; edits to them are drift,
+		// and a link that outlived its target, e.g. after a reboot, is
+		// resolved again
+		if _, ok := resolvedTarget(claudePath); ok {
+			if !symlink.IsValidSymlink(claudePath) {
+				return true
+			}
+			continue
+		}
+
+		target, err := os.Readlink(claudePath)
+		if err != nil || target != filepath.Join(currentPath, artifact.Name) {
+			return true
+		}
+	}
+
+	return false
+}
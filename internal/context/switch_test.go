@@ -0,0 +1,33 @@
+package context
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/1outres/cldenv/internal/config"
+)
+
+func TestSwitchRejectsInternalEntries(t *testing.T) {
+	m := newTestManager(t)
+	if err := m.SwitchContext(config.DefaultContext); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{config.CurrentLink, ".git", config.LockFile, "..", "default/.."} {
+		t.Run(name, func(t *testing.T) {
+			if err := m.SwitchContext(name); !errors.Is(err, ErrContextNotFound) {
+				t.Errorf("SwitchContext(%q) error = %v, want %v", name, err, ErrContextNotFound)
+			}
+		})
+	}
+
+	target, err := os.Readlink(m.currentLinkPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(m.cldenvDir, config.DefaultContext); target != want {
+		t.Errorf(".current points at %s, want %s", target, want)
+	}
+}
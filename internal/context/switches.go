@@ -0,0 +1,124 @@
+package context
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/1outres/cldenv/internal/config"
+)
+
+// maxSwitches is how many switches are kept in the switch log
+const maxSwitches = 100
+
+var ErrNoPreviousContext = errors.New("no previous context")
+
+// Switch is a recorded change of the active context
+type Switch struct {
+	// From is the context that was active before, empty if none was
+	From string    `json:"from"`
+	To   string    `json:"to"`
+	Time time.Time `json:"time"`
+}
+
+// Switches returns the recorded switches, newest first. The log is local to
+// this machine and holds the last 100 switches.
+func (m *Manager) Switches() ([]Switch, error) {
+	data, err := os.ReadFile(filepath.Join(m.cldenvDir, config.SwitchesFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read switch log: %w", err)
+	}
+
+	// One switch per line: time, from and to separated by tabs, oldest first
+	var switches []Switch
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 3 {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, fields[0])
+		if err != nil {
+			continue
+		}
+		switches = append(switches, Switch{From: fields[1], To: fields[2], Time: t})
+	}
+
+	for i, j := 0, len(switches)-1; i < j; i, j = i+1, j-1 {
+		switches[i], switches[j] = switches[j], switches[i]
+	}
+	return switches, nil
+}
+
+// PreviousContext returns the most recently used context other than the
+// active one, the target of 'cldenv use -'. Removed contexts are skipped.
+func (m *Manager) PreviousContext() (string, error) {
+	switches, err := m.Switches()
+	if err != nil {
+		return "", err
+	}
+
+	active := m.getActiveContext()
+	for _, s := range switches {
+		for _, name := range []string{s.To, s.From} {
+			if name != "" && name != active && m.ContextExists(name) {
+				return name, nil
+			}
+		}
+	}
+	return "", ErrNoPreviousContext
+}
+
+// recordSwitch appends a switch to the switch log. The log only serves
+// navigation, so failing to write it produces a warning.
+func (m *Manager) recordSwitch(from, to string) {
+	err := m.updateSwitches(func(switches []Switch) []Switch {
+		return append(switches, Switch{From: from, To: to, Time: time.Now().UTC().Truncate(time.Second)})
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to record switch to '%s': %v\n", to, err)
+	}
+}
+
+// renameSwitches points recorded switches involving oldName at newName
+func (m *Manager) renameSwitches(oldName, newName string) error {
+	return m.updateSwitches(func(switches []Switch) []Switch {
+		for i, s := range switches {
+			if s.From == oldName {
+				switches[i].From = newName
+			}
+			if s.To == oldName {
+				switches[i].To = newName
+			}
+		}
+		return switches
+	})
+}
+
+// updateSwitches rewrites the switch log, oldest first, with the result of fn
+func (m *Manager) updateSwitches(fn func([]Switch) []Switch) error {
+	newest, err := m.Switches()
+	if err != nil {
+		return err
+	}
+
+	switches := make([]Switch, len(newest))
+	for i, s := range newest {
+		switches[len(newest)-1-i] = s
+	}
+	switches = fn(switches)
+	if len(switches) > maxSwitches {
+		switches = switches[len(switches)-maxSwitches:]
+	}
+
+	var content strings.Builder
+	for _, s := range switches {
+		fmt.Fprintf(&content, "%s\t%s\t%s\n", s.Time.Format(time.RFC3339), s.From, s.To)
+	}
+	return os.WriteFile(filepath.Join(m.cldenvDir, config.SwitchesFile), []byte(content.String()), 0644)
+}
@@ -0,0 +1,431 @@
+package context
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/1outres/cldenv/internal/config"
+	"github.com/1outres/cldenv/internal/history"
+	"github.com/1outres/cldenv/pkg/jsonmerge"
+)
+
+// Resolution picks a side for a context that conflicted in a previous sync
+type Resolution string
+
+const (
+	ResolveLocal  Resolution = "local"
+	ResolveRemote Resolution = "remote"
+)
+
+// SyncResult summarizes a sync
+type SyncResult struct {
+	// Pulled lists contexts updated from the remote
+	Pulled []string `json:"pulled"`
+	// Pushed lists contexts whose local changes were uploaded
+	Pushed []string `json:"pushed"`
+	// Conflicts maps contexts left untouched to their conflicting files and keys
+	Conflicts map[string][]string `json:"conflicts"`
+}
+
+// SetSyncRemote configures the git remote used by Sync
+func (m *Manager) SetSyncRemote(url string) error {
+	remote, err := history.OpenRemote(m.cldenvDir)
+	if err != nil {
+		return err
+	}
+	return remote.SetURL(url)
+}
+
+// SyncRemote returns the configured sync remote URL
+func (m *Manager) SyncRemote() (string, error) {
+	remote, err := history.OpenRemote(m.cldenvDir)
+	if err != nil {
+		return "", err
+	}
+	return remote.URL()
+}
+
+// SetSyncExcluded marks a context as machine-local so it is never synced
+func (m *Manager) SetSyncExcluded(name string, excluded bool) error {
+	if !m.ContextExists(name) {
+		return ErrContextNotFound
+	}
+
+	unlock, err := m.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	meta, err := m.LoadMetadata(name)
+	if err != nil {
+		return err
+	}
+
+	meta.Local = excluded
+	return m.SaveMetadata(name, meta)
+}
+
+// Sync exchanges contexts with the sync remote. Each context is merged
+// three-way against the state of the previous sync: JSON files are merged key
+// by key, other files must only have changed on one side. Contexts with
+// conflicts are left untouched unless a resolution is given for them.
+func (m *Manager) Sync(resolutions map[string]Resolution) (*SyncResult, error) {
+	unlock, err := m.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	remote, err := history.OpenRemote(m.cldenvDir)
+	if err != nil {
+		return nil, err
+	}
+
+	base, theirs, parent, err := remote.Fetch()
+	if err != nil {
+		return nil, err
+	}
+
+	ours, excluded, err := m.syncTree()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]bool)
+	for _, tree := range []history.Tree{base, theirs, ours} {
+		for name := range tree {
+			if !excluded[name] {
+				names[name] = true
+			}
+		}
+	}
+
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	result := &SyncResult{Pulled: []string{}, Pushed: []string{}, Conflicts: make(map[string][]string)}
+	pushTree := history.Tree{}
+	baseTree := history.Tree{}
+	activeContext := m.getActiveContext()
+
+	for _, name := range sorted {
+		var merged map[string]history.File
+		var conflicts []string
+
+		switch resolutions[name] {
+		case ResolveLocal:
+			merged = ours[name]
+		case ResolveRemote:
+			merged = theirs[name]
+		default:
+			merged, conflicts = mergeContext(base[name], ours[name], theirs[name])
+		}
+
+		if merged == nil && ours[name] != nil && name == activeContext {
+			conflicts = append(conflicts, "removed on remote while active")
+		}
+
+		if len(conflicts) > 0 {
+			// Keep both sides as they are and the old base so the conflict persists
+			result.Conflicts[name] = conflicts
+			copyContextTree(pushTree, theirs, name)
+			copyContextTree(baseTree, base, name)
+			continue
+		}
+
+		if !sameTree(merged, ours[name]) {
+			if err := m.writeSyncedFiles(name, merged); err != nil {
+				return nil, err
+			}
+			m.record(fmt.Sprintf("sync %s", name), name)
+			result.Pulled = append(result.Pulled, name)
+
+			if merged != nil && name == activeContext {
+				if err := m.SwitchContext(name); err != nil {
+					return nil, fmt.Errorf("failed to refresh active context '%s': %w", name, err)
+				}
+			}
+		}
+
+		if !sameTree(merged, theirs[name]) {
+			result.Pushed = append(result.Pushed, name)
+		}
+
+		if merged != nil {
+			pushTree[name] = merged
+			baseTree[name] = merged
+		}
+	}
+
+	// Contexts excluded here may still be synced by other machines; carry
+	// them through unchanged rather than deleting them from the remote
+	for name := range excluded {
+		copyContextTree(pushTree, theirs, name)
+		copyContextTree(baseTree, base, name)
+	}
+
+	if len(result.Pushed) > 0 {
+		if err := remote.Push(pushTree, parent, syncMessage(result.Pushed)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := remote.SetBase(baseTree); err != nil {
+		return nil, fmt.Errorf("failed to record sync state: %w", err)
+	}
+
+	return result, nil
+}
+
+// syncTree reads all contexts that take part in syncing
+func (m *Manager) syncTree() (history.Tree, map[string]bool, error) {
+	entries, err := os.ReadDir(m.cldenvDir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read contexts directory: %w", err)
+	}
+
+	tree := history.Tree{}
+	excluded := make(map[string]bool)
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		meta, err := m.LoadMetadata(entry.Name())
+		if err != nil {
+			return nil, nil, err
+		}
+		if meta.Local {
+			excluded[entry.Name()] = true
+			continue
+		}
+
+		files, err := m.Files(entry.Name())
+		if err != nil {
+			return nil, nil, err
+		}
+		synced := make(map[string]history.File)
+		for path, content := range files {
+			if config.IsTempFile(path) {
+				continue
+			}
+			synced[path] = history.File{
+				Content:    content,
+				Executable: isExecutable(filepath.Join(m.cldenvDir, entry.Name(), filepath.FromSlash(path))),
+			}
+		}
+		tree[entry.Name()] = synced
+	}
+
+	return tree, excluded, nil
+}
+
+// writeContextFiles replaces the files of a context; nil files remove the context
+func (m *Manager) writeContextFiles(name string, files map[string][]byte) error {
+	contextPath := filepath.Join(m.cldenvDir, name)
+	if files == nil {
+		if err := os.RemoveAll(contextPath); err != nil {
+			return fmt.Errorf("failed to remove context '%s': %w", name, err)
+		}
+		return nil
+	}
+
+	current, _ := m.Files(name)
+	for path := range current {
+		if _, ok := files[path]; !ok {
+			if err := os.Remove(filepath.Join(contextPath, filepath.FromSlash(path))); err != nil {
+				return fmt.Errorf("failed to remove %s from context '%s': %w", path, name, err)
+			}
+		}
+	}
+
+	for path, content := range files {
+		target := filepath.Join(contextPath, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", path, err)
+		}
+		if err := os.WriteFile(target, content, 0644); err != nil {
+			return fmt.Errorf("failed to write %s to context '%s': %w", path, name, err)
+		}
+	}
+
+	return nil
+}
+
+// writeSyncedFiles replaces the files of a context with synced ones, which
+// keep whether they are executable; nil files remove the context
+func (m *Manager) writeSyncedFiles(name string, files map[string]history.File) error {
+	if files == nil {
+		return m.writeContextFiles(name, nil)
+	}
+
+	contents := make(map[string][]byte, len(files))
+	for path, file := range files {
+		contents[path] = file.Content
+	}
+	if err := m.writeContextFiles(name, contents); err != nil {
+		return err
+	}
+
+	for path, file := range files {
+		var mode os.FileMode = 0644
+		if file.Executable {
+			mode = 0755
+		}
+		if err := os.Chmod(filepath.Join(m.cldenvDir, name, filepath.FromSlash(path)), mode); err != nil {
+			return fmt.Errorf("failed to set mode of %s in context '%s': %w", path, name, err)
+		}
+	}
+	return nil
+}
+
+// isExecutable reports whether the file at path is executable
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode()&0111 != 0
+}
+
+// sameFile reports whether two synced files have the same content and mode
+func sameFile(a, b history.File) bool {
+	return bytes.Equal(a.Content, b.Content) && a.Executable == b.Executable
+}
+
+// mergeContext merges the files of a context three-way. A nil result means
+// the context was removed.
+func mergeContext(base, ours, theirs map[string]history.File) (map[string]history.File, []string) {
+	paths := make(map[string]bool)
+	for _, files := range []map[string]history.File{base, ours, theirs} {
+		for path := range files {
+			paths[path] = true
+		}
+	}
+
+	merged := make(map[string]history.File)
+	var conflicts []string
+	for path := range paths {
+		b, bok := base[path]
+		o, ook := ours[path]
+		t, tok := theirs[path]
+
+		switch {
+		case ook == tok && sameFile(o, t):
+		case bok == ook && sameFile(b, o):
+			o, ook = t, tok
+		case bok == tok && sameFile(b, t):
+		case ook && tok:
+			// Both sides changed the file; merge its content and mode separately
+			switch {
+			case o.Executable == t.Executable:
+			case bok && b.Executable == o.Executable:
+				o.Executable = t.Executable
+			case bok && b.Executable == t.Executable:
+			default:
+				conflicts = append(conflicts, fmt.Sprintf("%s: (executable)", path))
+			}
+
+			switch {
+			case bytes.Equal(o.Content, t.Content):
+			case bok && bytes.Equal(b.Content, o.Content):
+				o.Content = t.Content
+			case bok && bytes.Equal(b.Content, t.Content):
+			case strings.HasSuffix(path, ".json"):
+				content, keyConflicts := mergeJSON(path, b.Content, o.Content, t.Content)
+				for _, key := range keyConflicts {
+					conflicts = append(conflicts, fmt.Sprintf("%s: %s", path, key))
+				}
+				o.Content = content
+			default:
+				conflicts = append(conflicts, path)
+			}
+		default:
+			conflicts = append(conflicts, path)
+		}
+
+		if ook {
+			merged[path] = o
+		}
+	}
+	sort.Strings(conflicts)
+
+	if len(merged) == 0 && ours == nil || len(merged) == 0 && theirs == nil {
+		return nil, conflicts
+	}
+	return merged, conflicts
+}
+
+// parseDocument parses a JSON document, treating an empty file as an empty object
+func parseDocument(data []byte) (any, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return map[string]any{}, nil
+	}
+	var v any
+	err := json.Unmarshal(data, &v)
+	return v, err
+}
+
+// mergeJSON merges JSON documents key by key. Unparsable documents conflict as a whole.
+func mergeJSON(path string, base, ours, theirs []byte) ([]byte, []string) {
+	b, berr := parseDocument(base)
+	o, oerr := parseDocument(ours)
+	t, terr := parseDocument(theirs)
+	if berr != nil || oerr != nil || terr != nil {
+		return ours, []string{"(not valid JSON)"}
+	}
+
+	merged, conflicts := jsonmerge.ThreeWay(b, o, t)
+	if len(conflicts) > 0 {
+		return ours, conflicts
+	}
+
+	// Keep the existing formatting when one side already has the result
+	if reflect.DeepEqual(merged, t) {
+		return theirs, nil
+	}
+	if reflect.DeepEqual(merged, o) {
+		return ours, nil
+	}
+
+	data, err := json.MarshalIndent(merged, "", "  ")
+	if err != nil {
+		return ours, []string{"(not valid JSON)"}
+	}
+	return append(data, '\n'), nil
+}
+
+// sameTree reports whether two sets of context files are identical
+func sameTree(a, b map[string]history.File) bool {
+	if (a == nil) != (b == nil) || len(a) != len(b) {
+		return false
+	}
+	for path, file := range a {
+		other, ok := b[path]
+		if !ok || !sameFile(file, other) {
+			return false
+		}
+	}
+	return true
+}
+
+func copyContextTree(dst, src history.Tree, name string) {
+	if files, ok := src[name]; ok {
+		dst[name] = files
+	}
+}
+
+func syncMessage(contexts []string) string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown host"
+	}
+	return fmt.Sprintf("sync %s from %s", strings.Join(contexts, ", "), host)
+}
@@ -0,0 +1,260 @@
+package context
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/1outres/cldenv/internal/config"
+	"github.com/1outres/cldenv/internal/history"
+)
+
+func TestMergeContext(t *testing.T) {
+	file := func(content string) history.File { return history.File{Content: []byte(content)} }
+	script := func(content string) history.File { return history.File{Content: []byte(content), Executable: true} }
+
+	tests := []struct {
+		name      string
+		base      map[string]history.File
+		ours      map[string]history.File
+		theirs    map[string]history.File
+		want      map[string]history.File
+		conflicts []string
+	}{
+		{
+			name:   "files changed on different sides",
+			base:   map[string]history.File{"CLAUDE.md": file("a"), "commands/x.md": file("x")},
+			ours:   map[string]history.File{"CLAUDE.md": file("b"), "commands/x.md": file("x")},
+			theirs: map[string]history.File{"CLAUDE.md": file("a"), "commands/x.md": file("y")},
+			want:   map[string]history.File{"CLAUDE.md": file("b"), "commands/x.md": file("y")},
+		},
+		{
+			name:   "file added on one side",
+			base:   map[string]history.File{"CLAUDE.md": file("a")},
+			ours:   map[string]history.File{"CLAUDE.md": file("a")},
+			theirs: map[string]history.File{"CLAUDE.md": file("a"), "agents/new.md": file("n")},
+			want:   map[string]history.File{"CLAUDE.md": file("a"), "agents/new.md": file("n")},
+		},
+		{
+			name:   "file removed on one side",
+			base:   map[string]history.File{"CLAUDE.md": file("a"), "old.md": file("o")},
+			ours:   map[string]history.File{"CLAUDE.md": file("a")},
+			theirs: map[string]history.File{"CLAUDE.md": file("a"), "old.md": file("o")},
+			want:   map[string]history.File{"CLAUDE.md": file("a")},
+		},
+		{
+			name:      "text file changed on both sides",
+			base:      map[string]history.File{"CLAUDE.md": file("a")},
+			ours:      map[string]history.File{"CLAUDE.md": file("b")},
+			theirs:    map[string]history.File{"CLAUDE.md": file("c")},
+			want:      map[string]history.File{"CLAUDE.md": file("b")},
+			conflicts: []string{"CLAUDE.md"},
+		},
+		{
+			name:      "add/add of different text files",
+			ours:      map[string]history.File{"CLAUDE.md": file("b")},
+			theirs:    map[string]history.File{"CLAUDE.md": file("c")},
+			want:      map[string]history.File{"CLAUDE.md": file("b")},
+			conflicts: []string{"CLAUDE.md"},
+		},
+		{
+			name:      "delete/modify",
+			base:      map[string]history.File{"CLAUDE.md": file("a"), "notes.md": file("n")},
+			ours:      map[string]history.File{"CLAUDE.md": file("a")},
+			theirs:    map[string]history.File{"CLAUDE.md": file("a"), "notes.md": file("changed")},
+			want:      map[string]history.File{"CLAUDE.md": file("a")},
+			conflicts: []string{"notes.md"},
+		},
+		{
+			name:   "JSON files merge key by key",
+			base:   map[string]history.File{"settings.json": file(`{"model": "opus"}`)},
+			ours:   map[string]history.File{"settings.json": file(`{"model": "sonnet"}`)},
+			theirs: map[string]history.File{"settings.json": file(`{"model": "opus", "theme": "dark"}`)},
+			want:   map[string]history.File{"settings.json": file("{\n  \"model\": \"sonnet\",\n  \"theme\": \"dark\"\n}\n")},
+		},
+		{
+			name:      "JSON key conflicts name the key",
+			base:      map[string]history.File{"settings.json": file(`{"model": "opus"}`)},
+			ours:      map[string]history.File{"settings.json": file(`{"model": "sonnet"}`)},
+			theirs:    map[string]history.File{"settings.json": file(`{"model": "haiku"}`)},
+			want:      map[string]history.File{"settings.json": file(`{"model": "sonnet"}`)},
+			conflicts: []string{"settings.json: model"},
+		},
+		{
+			name:      "invalid JSON conflicts as a whole",
+			base:      map[string]history.File{"settings.json": file(`{}`)},
+			ours:      map[string]history.File{"settings.json": file(`{"model": `)},
+			theirs:    map[string]history.File{"settings.json": file(`{"model": "haiku"}`)},
+			want:      map[string]history.File{"settings.json": file(`{"model": `)},
+			conflicts: []string{"settings.json: (not valid JSON)"},
+		},
+		{
+			name:   "executable bit changed on one side",
+			base:   map[string]history.File{"hooks/lint.sh": file("lint")},
+			ours:   map[string]history.File{"hooks/lint.sh": file("lint")},
+			theirs: map[string]history.File{"hooks/lint.sh": script("lint")},
+			want:   map[string]history.File{"hooks/lint.sh": script("lint")},
+		},
+		{
+			name:   "executable bit and content changed on different sides",
+			base:   map[string]history.File{"hooks/lint.sh": file("lint")},
+			ours:   map[string]history.File{"hooks/lint.sh": script("lint")},
+			theirs: map[string]history.File{"hooks/lint.sh": file("lint --fix")},
+			want:   map[string]history.File{"hooks/lint.sh": script("lint --fix")},
+		},
+		{
+			name:      "add/add with different executable bits",
+			ours:      map[string]history.File{"hooks/lint.sh": script("lint")},
+			theirs:    map[string]history.File{"hooks/lint.sh": file("lint")},
+			want:      map[string]history.File{"hooks/lint.sh": script("lint")},
+			conflicts: []string{"hooks/lint.sh: (executable)"},
+		},
+		{
+			name:   "context removed on one side",
+			base:   map[string]history.File{"CLAUDE.md": file("a")},
+			theirs: map[string]history.File{"CLAUDE.md": file("a")},
+			want:   nil,
+		},
+		{
+			name:      "context removed on one side and changed on the other",
+			base:      map[string]history.File{"CLAUDE.md": file("a")},
+			theirs:    map[string]history.File{"CLAUDE.md": file("b")},
+			want:      nil,
+			conflicts: []string{"CLAUDE.md"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, conflicts := mergeContext(tt.base, tt.ours, tt.theirs)
+			if !reflect.DeepEqual(conflicts, tt.conflicts) {
+				t.Errorf("mergeContext() conflicts = %q, want %q", conflicts, tt.conflicts)
+			}
+			if !sameTree(got, tt.want) {
+				t.Errorf("mergeContext() = %s, want %s", describeFiles(got), describeFiles(tt.want))
+			}
+		})
+	}
+}
+
+func TestSync(t *testing.T) {
+	remote := t.TempDir()
+	if out, err := exec.Command("git", "init", "--bare", remote).CombinedOutput(); err != nil {
+		t.Fatalf("git init --bare: %v\n%s", err, out)
+	}
+
+	// Two machines sharing the remote; their default contexts stay local
+	machine := func() *Manager {
+		m := newTestManager(t)
+		if err := m.SetSyncExcluded(config.DefaultContext, true); err != nil {
+			t.Fatal(err)
+		}
+		if err := m.SetSyncRemote(remote); err != nil {
+			t.Fatal(err)
+		}
+		return m
+	}
+	sync := func(m *Manager, resolutions map[string]Resolution) *SyncResult {
+		t.Helper()
+		result, err := m.Sync(resolutions)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	a := machine()
+	if err := a.CreateContext("shared", CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	writeSettings(t, a, "shared", `{"model": "opus", "env": {"A": "1"}}`)
+	if result := sync(a, nil); !reflect.DeepEqual(result.Pushed, []string{"shared"}) {
+		t.Fatalf("first sync pushed %v, want [shared]", result.Pushed)
+	}
+
+	b := machine()
+	if result := sync(b, nil); !reflect.DeepEqual(result.Pulled, []string{"shared"}) || len(result.Pushed) != 0 {
+		t.Fatalf("second machine pulled %v and pushed %v, want only [shared] pulled", result.Pulled, result.Pushed)
+	}
+	if got := readSettings(t, b, "shared"); got["model"] != "opus" {
+		t.Fatalf("pulled settings = %v", got)
+	}
+
+	// Different keys changed on each machine merge
+	writeSettings(t, a, "shared", `{"model": "sonnet", "env": {"A": "1"}}`)
+	sync(a, nil)
+	writeSettings(t, b, "shared", `{"model": "opus", "env": {"A": "1", "B": "2"}}`)
+	result := sync(b, nil)
+	if !reflect.DeepEqual(result.Pulled, []string{"shared"}) || !reflect.DeepEqual(result.Pushed, []string{"shared"}) {
+		t.Fatalf("merging sync pulled %v and pushed %v, want [shared] for both", result.Pulled, result.Pushed)
+	}
+	sync(a, nil)
+	want := map[string]any{"model": "sonnet", "env": map[string]any{"A": "1", "B": "2"}}
+	for _, m := range []*Manager{a, b} {
+		if got := readSettings(t, m, "shared"); !reflect.DeepEqual(got, want) {
+			t.Errorf("merged settings in %s = %v, want %v", m.cldenvDir, got, want)
+		}
+	}
+
+	// The same key changed on both machines conflicts until resolved
+	writeSettings(t, a, "shared", `{"model": "haiku", "env": {"A": "1", "B": "2"}}`)
+	sync(a, nil)
+	writeSettings(t, b, "shared", `{"model": "opus", "env": {"A": "1", "B": "2"}}`)
+	result = sync(b, nil)
+	if got := result.Conflicts["shared"]; !reflect.DeepEqual(got, []string{"settings.json: model"}) {
+		t.Fatalf("conflicts = %v, want [settings.json: model]", got)
+	}
+	if got := readSettings(t, b, "shared"); got["model"] != "opus" {
+		t.Errorf("conflicting sync changed local settings to %v", got)
+	}
+	if result := sync(b, nil); len(result.Conflicts["shared"]) == 0 {
+		t.Error("conflict was dropped by the next sync")
+	}
+	sync(b, map[string]Resolution{"shared": ResolveRemote})
+	if got := readSettings(t, b, "shared"); got["model"] != "haiku" {
+		t.Errorf("settings after resolving to remote = %v, want model haiku", got)
+	}
+
+	// Local contexts never reach the remote
+	if got := sync(a, nil); len(got.Pulled) != 0 || len(got.Conflicts) != 0 {
+		t.Errorf("final sync pulled %v with conflicts %v", got.Pulled, got.Conflicts)
+	}
+	if out, err := exec.Command("git", "--git-dir", remote, "ls-tree", "-r", "--name-only", "cldenv").Output(); err != nil {
+		t.Fatal(err)
+	} else if files := string(out); !strings.Contains(files, "shared/settings.json\n") || strings.Contains("\n"+files, "\n"+config.DefaultContext+"/") {
+		t.Errorf("remote tree:\n%s", files)
+	}
+}
+
+// readSettings decodes settings.json of a context
+func readSettings(t *testing.T, m *Manager, name string) map[string]any {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(m.cldenvDir, name, config.SettingsFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var settings map[string]any
+	if err := json.Unmarshal(data, &settings); err != nil {
+		t.Fatal(err)
+	}
+	return settings
+}
+
+func describeFiles(files map[string]history.File) string {
+	if files == nil {
+		return "<removed>"
+	}
+	described := make(map[string]string, len(files))
+	for path, file := range files {
+		mode := ""
+		if file.Executable {
+			mode = " (executable)"
+		}
+		described[path] = string(file.Content) + mode
+	}
+	data, _ := json.Marshal(described)
+	return string(data)
+}
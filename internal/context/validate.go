@@ -0,0 +1,98 @@
+package context
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/1outres/cldenv/internal/config"
+	"github.com/1outres/cldenv/internal/schema"
+)
+
+var ErrInvalidSettings = errors.New("invalid settings.json")
+
+// ValidationIssue is a schema issue in the settings.json of a context
+type ValidationIssue struct {
+	Context string `json:"context"`
+	schema.Issue
+}
+
+func (i ValidationIssue) String() string {
+	return fmt.Sprintf("%s/%s: %s", i.Context, config.SettingsFile, i.Issue)
+}
+
+// ValidateContext checks settings.json of a context and of every context it
+// inherits from
+func (m *Manager) ValidateContext(name string) ([]ValidationIssue, error) {
+	if !m.ContextExists(name) {
+		return nil, ErrContextNotFound
+	}
+
+	chain, err := m.ResolveChain(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []ValidationIssue
+	found := false
+	layered := len(chain) > 1
+	for _, layer := range chain {
+		data, err := os.ReadFile(filepath.Join(m.cldenvDir, layer, config.SettingsFile))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read settings.json of '%s': %w", layer, err)
+		}
+
+		found = true
+		for _, issue := range validateLayer(data, layered) {
+			issues = append(issues, ValidationIssue{Context: layer, Issue: issue})
+		}
+	}
+
+	if !found {
+		issues = append(issues, ValidationIssue{
+			Context: name,
+			Issue:   schema.Issue{Severity: schema.Warning, Message: "file is missing"},
+		})
+	}
+
+	return issues, nil
+}
+
+// Validate checks settings.json of every context in the archive
+func (a *Archive) Validate() []ValidationIssue {
+	var issues []ValidationIssue
+	for _, ctx := range a.Manifest.Contexts {
+		data, ok := a.files[ctx.Name][config.SettingsFile]
+		if !ok {
+			continue
+		}
+		for _, issue := range validateLayer(data, ctx.Extends != "") {
+			issues = append(issues, ValidationIssue{Context: ctx.Name, Issue: issue})
+		}
+	}
+	return issues
+}
+
+// validateLayer checks settings.json of one context. In a layered context an
+// empty file adds no overrides, as when rendering, so it is not an error.
+func validateLayer(data []byte, layered bool) []schema.Issue {
+	if layered && len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+	return schema.ValidateSettings(data)
+}
+
+// HasValidationErrors reports whether any issue is an error
+func HasValidationErrors(issues []ValidationIssue) bool {
+	for _, issue := range issues {
+		if issue.Severity == schema.Error {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package context
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/1outres/cldenv/internal/config"
+)
+
+func TestValidateLayeredEmptySettings(t *testing.T) {
+	m := newTestManager(t)
+	if err := m.CreateContext("child", CreateOptions{Extends: config.DefaultContext}); err != nil {
+		t.Fatal(err)
+	}
+	settings := filepath.Join(m.cldenvDir, "child", config.SettingsFile)
+	if err := os.WriteFile(settings, []byte(" \n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	issues, err := m.ValidateContext("child")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if HasValidationErrors(issues) {
+		t.Errorf("empty settings.json of a layered context is an error: %v", issues)
+	}
+
+	// Without a parent the file is linked as is, which Claude Code cannot read
+	if err := os.WriteFile(filepath.Join(m.cldenvDir, config.DefaultContext, config.SettingsFile), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	issues, err = m.ValidateContext(config.DefaultContext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !HasValidationErrors(issues) {
+		t.Error("empty settings.json of a standalone context is not an error")
+	}
+}
@@ -9,13 +9,38 @@ import (
 var (
 	// Reserved context names that cannot be used
 	reservedNames = map[string]bool{
-		"help":    true,
-		"version": true,
-		"use":     true,
-		"create":  true,
-		"remove":  true,
-		"list":    true,
-		"switch":  true,
+		"help":        true,
+		"version":     true,
+		"use":         true,
+		"create":      true,
+		"remove":      true,
+		"list":        true,
+		"switch":      true,
+		"local":       true,
+		"current":     true,
+		"hook":        true,
+		"show":        true,
+		"doctor":      true,
+		"adopt":       true,
+		"watch":       true,
+		"history":     true,
+		"diff":        true,
+		"rollback":    true,
+		"edit":        true,
+		"sync":        true,
+		"export":      true,
+		"import":      true,
+		"validate":    true,
+		"rename":      true,
+		"copy":        true,
+		"set-default": true,
+		"describe":    true,
+		"exec":        true,
+		"mode":        true,
+		"credentials": true,
+		"env":         true,
+		"shell":       true,
+		"secret":      true,
 	}
 
 	// Valid context name pattern: alphanumeric, dash, underscore
@@ -49,4 +74,4 @@ func ValidateContextName(name string) error {
 	}
 
 	return nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,153 @@
+package context
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"time"
+
+	"github.com/1outres/cldenv/internal/config"
+	"github.com/1outres/cldenv/pkg/dirwatch"
+)
+
+// watchSettleDelay is how long the watcher waits for a burst of events to
+// end before reconciling, so editors can finish writing
+const watchSettleDelay = 200 * time.Millisecond
+
+// Reconcile adopts content that replaced managed links and restores missing
+// or broken links of the active context. It returns a description of every
+// action taken.
+func (m *Manager) Reconcile() ([]string, error) {
+	var actions []string
+	var errs []error
+
+	for _, drift := range m.DetectDrift() {
+		if err := m.Adopt(drift); err != nil {
+			errs = append(errs, fmt.Errorf("failed to adopt %s: %w", drift.Artifact.DisplayName(), err))
+			continue
+		}
+		actions = append(actions, fmt.Sprintf("adopted %s into context '%s'", drift.Artifact.DisplayName(), drift.Context))
+	}
+
+	if m.needsRelink() {
+		if err := m.relinkActive(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to restore links: %w", err))
+		} else {
+			actions = append(actions, fmt.Sprintf("restored links to context '%s'", m.getActiveContext()))
+		}
+	}
+
+	return actions, errors.Join(errs...)
+}
+
+// Watch keeps the links in ~/.claude intact until stop is closed. Every
+// change to a managed artifact is logged and reconciled.
+func (m *Manager) Watch(logger *log.Logger, stop <-chan struct{}) error {
+	for {
+		restart, err := m.watchHome(logger, stop)
+		if !restart {
+			return err
+		}
+	}
+}
+
+// watchHome watches the directory ~/.claude resolves to. In isolation mode it
+// returns true once a switch moved ~/.claude to another home directory, so the
+// watch can be restarted there.
+func (m *Manager) watchHome(logger *log.Logger, stop <-chan struct{}) (bool, error) {
+	if err := config.CreateDir(m.claudeDir); err != nil {
+		return false, fmt.Errorf("failed to create claude directory: %w", err)
+	}
+
+	// inotify does not follow links, and in isolation mode ~/.claude links to
+	// the active home directory. The .home link itself is watched in ~/.cldenv.
+	home := m.activeHome()
+	claudeDir, err := filepath.EvalSymlinks(m.claudeDir)
+	if err != nil {
+		return false, fmt.Errorf("failed to resolve claude directory: %w", err)
+	}
+	watcher, err := dirwatch.New(claudeDir, m.cldenvDir)
+	if err != nil {
+		return false, err
+	}
+
+	// done lets the reader exit when the watch restarts in another home
+	events := make(chan dirwatch.Event)
+	errc := make(chan error, 1)
+	done := make(chan struct{})
+	go func() {
+		for {
+			event, err := watcher.Next()
+			if err != nil {
+				errc <- err
+				return
+			}
+			select {
+			case events <- event:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	defer watcher.Close()
+	defer close(done)
+
+	logger.Printf("watching %s and %s", claudeDir, m.cldenvDir)
+	m.reconcileAndLog(logger)
+
+	var settle <-chan time.Time
+	for {
+		select {
+		case <-stop:
+			logger.Printf("stopped")
+			return false, nil
+		case err := <-errc:
+			return false, err
+		case event := <-events:
+			if !m.isWatchedEvent(event) {
+				continue
+			}
+			logger.Printf("%s %s/%s", event.Op, event.Dir, event.Name)
+			settle = time.After(watchSettleDelay)
+		case <-settle:
+			settle = nil
+			m.reconcileAndLog(logger)
+			if m.activeHome() != home {
+				logger.Printf("home directory changed, watching %s again", m.claudeDir)
+				return true, nil
+			}
+		}
+	}
+}
+
+// reconcileAndLog runs Reconcile and logs its outcome
+func (m *Manager) reconcileAndLog(logger *log.Logger) {
+	actions, err := m.Reconcile()
+	for _, action := range actions {
+		logger.Print(action)
+	}
+	if err != nil {
+		logger.Printf("error: %v", err)
+	}
+}
+
+// isWatchedEvent reports whether an event concerns a managed artifact or the context pointer
+func (m *Manager) isWatchedEvent(event dirwatch.Event) bool {
+	// Temporary links created while switching are not interesting
+	if config.IsTempFile(event.Name) {
+		return false
+	}
+
+	if event.Dir == m.cldenvDir {
+		return event.Name == config.CurrentLink || event.Name == config.HomeLink
+	}
+
+	for _, artifact := range config.Artifacts {
+		if event.Name == artifact.Name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+//go:build linux
+
+package context
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer collects log output written from the watcher goroutine
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// waitFor polls until the log contains want or the watcher stopped
+func waitFor(t *testing.T, logs *syncBuffer, done <-chan error, want string) {
+	t.Helper()
+	deadline := time.After(10 * time.Second)
+	for !strings.Contains(logs.String(), want) {
+		select {
+		case err := <-done:
+			t.Fatalf("watch stopped before logging %q: %v\n%s", want, err, logs.String())
+		case <-deadline:
+			t.Fatalf("timed out waiting for %q\n%s", want, logs.String())
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+}
+
+func TestWatchIsolated(t *testing.T) {
+	m := newTestManager(t)
+	claudeDir := m.claudeDir
+	if err := m.CreateContext("work", CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Isolate(); err != nil {
+		t.Fatal(err)
+	}
+
+	logs := &syncBuffer{}
+	stop := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		watcher, err := NewManager()
+		if err != nil {
+			done <- err
+			return
+		}
+		done <- watcher.Watch(log.New(logs, "", 0), stop)
+	}()
+
+	waitFor(t, logs, done, "watching "+m.homeDir("default"))
+
+	if err := m.SwitchContext("work"); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, logs, done, "watching "+m.homeDir("work"))
+
+	// A link replaced in the new home is restored
+	settings := filepath.Join(claudeDir, "settings.json")
+	if err := os.Remove(settings); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, logs, done, "restored links to context 'work'")
+	if _, err := os.Readlink(settings); err != nil {
+		t.Errorf("settings.json was not relinked: %v", err)
+	}
+
+	close(stop)
+	if err := <-done; err != nil {
+		t.Fatalf("watch failed: %v", err)
+	}
+}
@@ -0,0 +1,255 @@
+package history
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/1outres/cldenv/internal/config"
+)
+
+// gitIgnore lists the internal state that is never versioned
+var gitIgnore = []string{
+	config.LockFile,
+	config.CurrentLink,
+	config.PinsFile,
+	config.SwitchesFile,
+	config.LastUsedFile,
+	config.RenderedDir + "/",
+	config.ExecDir + "/",
+	config.HomesDir + "/",
+	config.HomeLink,
+	snapshotsDir + "/",
+	// Temporary files, see config.IsTempFile
+	"*.tmp-[0-9]*",
+	// Sensitive files anywhere, including the login stashed at the root
+	config.CredentialsFile,
+}
+
+// obsoleteIgnore lists patterns written by older versions that ignored too much
+var obsoleteIgnore = []string{"*.tmp-*"}
+
+// gitStore keeps history in a git repository at the root of the cldenv directory
+type gitStore struct {
+	dir         string
+	initialized bool
+	identity    []string
+}
+
+// git runs a git command against the cldenv repository
+func (s *gitStore) git(args ...string) ([]byte, error) {
+	return s.run(nil, nil, args...)
+}
+
+// run runs a git command with extra environment variables and standard input
+func (s *gitStore) run(env []string, stdin []byte, args ...string) ([]byte, error) {
+	base := []string{
+		"--git-dir=" + filepath.Join(s.dir, ".git"),
+		"--work-tree=" + s.dir,
+		"-c", "commit.gpgsign=false",
+	}
+	base = append(base, s.identity...)
+
+	cmd := exec.Command("git", append(base, args...)...)
+	cmd.Dir = s.dir
+	cmd.Env = append(os.Environ(), env...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return out, fmt.Errorf("git %s: %s", args[0], msg)
+	}
+	return out, nil
+}
+
+// init creates the repository on first use
+func (s *gitStore) init() error {
+	if s.initialized {
+		return nil
+	}
+
+	if !config.FileExists(filepath.Join(s.dir, ".git")) {
+		if err := config.CreateDir(s.dir); err != nil {
+			return fmt.Errorf("failed to create cldenv directory: %w", err)
+		}
+		if _, err := s.git("init", "-q"); err != nil {
+			return err
+		}
+	}
+
+	// Commit as cldenv unless the user configured an identity
+	if out, err := s.git("config", "--get", "user.email"); err != nil || len(bytes.TrimSpace(out)) == 0 {
+		s.identity = []string{"-c", "user.name=cldenv", "-c", "user.email=cldenv@localhost"}
+	}
+
+	// Repositories created by older versions miss newer entries; add them so
+	// sensitive files are never committed, and drop entries that ignored too much
+	ignorePath := filepath.Join(s.dir, ".gitignore")
+	existing, err := os.ReadFile(ignorePath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read .gitignore: %w", err)
+	}
+	isObsolete := func(line string) bool { return slices.Contains(obsoleteIgnore, line) }
+	lines := strings.Split(string(existing), "\n")
+	obsolete := slices.ContainsFunc(lines, isObsolete)
+	lines = slices.DeleteFunc(lines, isObsolete)
+	var missing []string
+	for _, pattern := range gitIgnore {
+		if !slices.Contains(lines, pattern) {
+			missing = append(missing, pattern)
+		}
+	}
+	if len(missing) > 0 || obsolete {
+		content := strings.Join(lines, "\n")
+		if content != "" && !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		if len(missing) > 0 {
+			content += strings.Join(missing, "\n") + "\n"
+		}
+		if err := os.WriteFile(ignorePath, []byte(content), 0644); err != nil {
+			return fmt.Errorf("failed to write .gitignore: %w", err)
+		}
+		if _, err := s.git("add", "--", ".gitignore"); err != nil {
+			return err
+		}
+	}
+
+	s.initialized = true
+	return nil
+}
+
+func (s *gitStore) Commit(message string, contexts ...string) error {
+	if err := s.init(); err != nil {
+		return err
+	}
+
+	for _, context := range contexts {
+		if config.FileExists(filepath.Join(s.dir, context)) {
+			if _, err := s.git("add", "-A", "--", context); err != nil {
+				return err
+			}
+		} else if _, err := s.git("rm", "-r", "-q", "--cached", "--ignore-unmatch", "--", context); err != nil {
+			return err
+		}
+	}
+
+	// Nothing to record
+	if _, err := s.git("diff", "--cached", "--quiet"); err == nil {
+		return nil
+	}
+
+	_, err := s.git("commit", "-q", "-m", message)
+	return err
+}
+
+func (s *gitStore) Log(context string) ([]Revision, error) {
+	if err := s.init(); err != nil {
+		return nil, err
+	}
+
+	// An empty repository has no history yet
+	if _, err := s.git("rev-parse", "-q", "--verify", "HEAD"); err != nil {
+		return nil, nil
+	}
+
+	out, err := s.git("log", "--format=%h%x00%at%x00%s", "--", context+"/")
+	if err != nil {
+		return nil, err
+	}
+
+	var revisions []Revision
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		fields := strings.SplitN(line, "\x00", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		seconds, _ := strconv.ParseInt(fields[1], 10, 64)
+		revisions = append(revisions, Revision{
+			ID:      fields[0],
+			Time:    time.Unix(seconds, 0),
+			Message: fields[2],
+		})
+	}
+
+	return revisions, nil
+}
+
+// resolve verifies a revision and returns its full hash
+func (s *gitStore) resolve(rev string) (string, error) {
+	out, err := s.git("rev-parse", "-q", "--verify", rev+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrRevisionNotFound, rev)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func (s *gitStore) Files(context, rev string) (map[string][]byte, error) {
+	if err := s.init(); err != nil {
+		return nil, err
+	}
+
+	hash, err := s.resolve(rev)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := s.git("ls-tree", "-r", "-z", "--name-only", hash, "--", context+"/")
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string][]byte)
+	for _, path := range strings.Split(string(out), "\x00") {
+		if path == "" {
+			continue
+		}
+		content, err := s.git("show", hash+":"+path)
+		if err != nil {
+			return nil, err
+		}
+		files[strings.TrimPrefix(path, context+"/")] = content
+	}
+
+	return files, nil
+}
+
+func (s *gitStore) Restore(context, rev string) error {
+	if err := s.init(); err != nil {
+		return err
+	}
+
+	hash, err := s.resolve(rev)
+	if err != nil {
+		return err
+	}
+
+	// Check the revision has the context before touching the working tree
+	if out, err := s.git("ls-tree", "--name-only", hash, "--", context); err != nil || len(bytes.TrimSpace(out)) == 0 {
+		return fmt.Errorf("%w: context '%s' does not exist at %s", ErrRevisionNotFound, context, rev)
+	}
+
+	if err := os.RemoveAll(filepath.Join(s.dir, context)); err != nil {
+		return fmt.Errorf("failed to clear context directory: %w", err)
+	}
+	if _, err := s.git("rm", "-r", "-q", "--cached", "--ignore-unmatch", "--", context); err != nil {
+		return err
+	}
+
+	_, err = s.git("checkout", hash, "--", context+"/")
+	return err
+}
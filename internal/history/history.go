@@ -0,0 +1,39 @@
+package history
+
+import (
+	"errors"
+	"os/exec"
+	"time"
+)
+
+var (
+	ErrRevisionNotFound = errors.New("revision not found")
+)
+
+// Revision is a recorded state of a context
+type Revision struct {
+	ID      string    `json:"id"`
+	Time    time.Time `json:"time"`
+	Message string    `json:"message"`
+}
+
+// Store records and restores the history of contexts in the cldenv directory
+type Store interface {
+	// Commit records the current state of the given contexts
+	Commit(message string, contexts ...string) error
+	// Log returns the revisions touching a context, newest first
+	Log(context string) ([]Revision, error)
+	// Files returns the files of a context at a revision, keyed by relative path
+	Files(context, rev string) (map[string][]byte, error)
+	// Restore replaces the files of a context with those at a revision
+	Restore(context, rev string) error
+}
+
+// Open returns the history store for a cldenv directory: a git repository if
+// git is installed, or a built-in snapshot store otherwise
+func Open(cldenvDir string) Store {
+	if _, err := exec.LookPath("git"); err == nil {
+		return &gitStore{dir: cldenvDir}
+	}
+	return &snapshotStore{dir: cldenvDir}
+}
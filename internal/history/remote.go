@@ -0,0 +1,223 @@
+package history
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	ErrGitUnavailable = errors.New("git is not installed")
+	ErrNoRemote       = errors.New("no sync remote configured")
+	ErrRemoteChanged  = errors.New("remote changed during sync")
+)
+
+const (
+	remoteName   = "sync"
+	remoteBranch = "refs/heads/cldenv"
+	remoteRef    = "refs/cldenv/remote"
+	syncedRef    = "refs/cldenv/synced"
+)
+
+// Tree holds the files of several contexts: context name -> relative path -> file
+type Tree map[string]map[string]File
+
+// File is a file of a context in a Tree
+type File struct {
+	Content    []byte
+	Executable bool
+}
+
+// Remote exchanges context trees with a git remote. The merge base of the
+// next sync is kept in a local ref.
+type Remote struct {
+	store *gitStore
+}
+
+// OpenRemote returns the sync remote of a cldenv directory
+func OpenRemote(cldenvDir string) (*Remote, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, ErrGitUnavailable
+	}
+
+	store := &gitStore{dir: cldenvDir}
+	if err := store.init(); err != nil {
+		return nil, err
+	}
+	return &Remote{store: store}, nil
+}
+
+// URL returns the configured remote URL
+func (r *Remote) URL() (string, error) {
+	out, err := r.store.git("remote", "get-url", remoteName)
+	if err != nil {
+		return "", ErrNoRemote
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// SetURL configures the remote URL
+func (r *Remote) SetURL(url string) error {
+	if _, err := r.URL(); err == nil {
+		_, err := r.store.git("remote", "set-url", remoteName, url)
+		return err
+	}
+	_, err := r.store.git("remote", "add", remoteName, url)
+	return err
+}
+
+// Fetch downloads the remote tree. It returns the merge base, the remote tree
+// and the remote commit to build on, which is empty if the remote has no
+// contexts yet.
+func (r *Remote) Fetch() (base, remote Tree, commit string, err error) {
+	if _, err := r.URL(); err != nil {
+		return nil, nil, "", err
+	}
+
+	out, err := r.store.git("ls-remote", remoteName, remoteBranch)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	remote = Tree{}
+	if len(strings.TrimSpace(string(out))) > 0 {
+		if _, err := r.store.git("fetch", "-q", remoteName, "+"+remoteBranch+":"+remoteRef); err != nil {
+			return nil, nil, "", err
+		}
+		if commit, err = r.resolve(remoteRef); err != nil {
+			return nil, nil, "", err
+		}
+		if remote, err = r.readTree(commit); err != nil {
+			return nil, nil, "", err
+		}
+	}
+
+	base = Tree{}
+	if synced, err := r.resolve(syncedRef); err == nil {
+		if base, err = r.readTree(synced); err != nil {
+			return nil, nil, "", err
+		}
+	}
+
+	return base, remote, commit, nil
+}
+
+// Push uploads a tree as a new commit on top of parent. It fails with
+// ErrRemoteChanged if the remote moved since Fetch.
+func (r *Remote) Push(tree Tree, parent, message string) error {
+	var parents []string
+	if parent != "" {
+		parents = append(parents, parent)
+	}
+
+	commit, err := r.commitTree(tree, message, parents...)
+	if err != nil {
+		return err
+	}
+
+	if _, err := r.store.git("push", "-q", remoteName, commit+":"+remoteBranch); err != nil {
+		if strings.Contains(err.Error(), "rejected") {
+			return fmt.Errorf("%w: %v", ErrRemoteChanged, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// SetBase records the tree used as merge base by the next sync
+func (r *Remote) SetBase(tree Tree) error {
+	commit, err := r.commitTree(tree, "cldenv sync base")
+	if err != nil {
+		return err
+	}
+
+	_, err = r.store.git("update-ref", syncedRef, commit)
+	return err
+}
+
+// commitTree writes a tree to the object store and returns a commit of it
+func (r *Remote) commitTree(tree Tree, message string, parents ...string) (string, error) {
+	index := filepath.Join(r.store.dir, ".git", "cldenv-sync-index")
+	defer os.Remove(index)
+	env := []string{"GIT_INDEX_FILE=" + index}
+
+	if _, err := r.store.run(env, nil, "read-tree", "--empty"); err != nil {
+		return "", err
+	}
+
+	for context, files := range tree {
+		for path, file := range files {
+			blob, err := r.store.run(nil, file.Content, "hash-object", "-w", "--stdin")
+			if err != nil {
+				return "", err
+			}
+			mode := "100644"
+			if file.Executable {
+				mode = "100755"
+			}
+			info := fmt.Sprintf("%s,%s,%s/%s", mode, strings.TrimSpace(string(blob)), context, path)
+			if _, err := r.store.run(env, nil, "update-index", "--add", "--cacheinfo", info); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	treeID, err := r.store.run(env, nil, "write-tree")
+	if err != nil {
+		return "", err
+	}
+
+	args := []string{"commit-tree", strings.TrimSpace(string(treeID)), "-m", message}
+	for _, parent := range parents {
+		args = append(args, "-p", parent)
+	}
+	commit, err := r.store.git(args...)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(commit)), nil
+}
+
+// resolve returns the commit a ref points to
+func (r *Remote) resolve(ref string) (string, error) {
+	out, err := r.store.git("rev-parse", "-q", "--verify", ref+"^{commit}")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// readTree reads all files of a commit into a tree
+func (r *Remote) readTree(commit string) (Tree, error) {
+	out, err := r.store.git("ls-tree", "-r", "-z", commit)
+	if err != nil {
+		return nil, err
+	}
+
+	tree := Tree{}
+	for _, entry := range strings.Split(string(out), "\x00") {
+		// <mode> <type> <object>\t<path>
+		info, path, ok := strings.Cut(entry, "\t")
+		if !ok {
+			continue
+		}
+		context, rel, ok := strings.Cut(path, "/")
+		if !ok {
+			continue
+		}
+		content, err := r.store.git("show", commit+":"+path)
+		if err != nil {
+			return nil, err
+		}
+		if tree[context] == nil {
+			tree[context] = make(map[string]File)
+		}
+		tree[context][rel] = File{Content: content, Executable: strings.HasPrefix(info, "100755")}
+	}
+
+	return tree, nil
+}
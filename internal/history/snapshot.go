@@ -0,0 +1,183 @@
+package history
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/1outres/cldenv/internal/config"
+)
+
+const (
+	snapshotsDir    = ".snapshots"
+	snapshotFiles   = "files"
+	snapshotMessage = "message"
+	snapshotIDTime  = "20060102T150405.000000000"
+)
+
+// snapshotStore keeps full copies of contexts in ~/.cldenv/.snapshots when git is not available
+type snapshotStore struct {
+	dir string
+}
+
+// contextDir returns the directory holding the snapshots of a context
+func (s *snapshotStore) contextDir(context string) string {
+	return filepath.Join(s.dir, snapshotsDir, context)
+}
+
+func (s *snapshotStore) Commit(message string, contexts ...string) error {
+	for _, context := range contexts {
+		src := filepath.Join(s.dir, context)
+
+		// Nothing to record if the context is unchanged since the last snapshot
+		if revisions, err := s.Log(context); err == nil && len(revisions) > 0 {
+			files, err := s.Files(context, revisions[0].ID)
+			if err == nil && sameFiles(files, src) {
+				continue
+			}
+		}
+
+		id := time.Now().UTC().Format(snapshotIDTime)
+		snapshot := filepath.Join(s.contextDir(context), id)
+		if err := config.CreateDir(filepath.Join(snapshot, snapshotFiles)); err != nil {
+			return fmt.Errorf("failed to create snapshot: %w", err)
+		}
+
+		// A removed context is recorded as an empty snapshot
+		if config.FileExists(src) {
+			if err := config.CopyDir(src, filepath.Join(snapshot, snapshotFiles)); err != nil {
+				return fmt.Errorf("failed to copy context into snapshot: %w", err)
+			}
+			for _, artifact := range config.Artifacts {
+				if artifact.Sensitive {
+					os.Remove(filepath.Join(snapshot, snapshotFiles, artifact.Name))
+				}
+			}
+		}
+
+		if err := os.WriteFile(filepath.Join(snapshot, snapshotMessage), []byte(message+"\n"), 0644); err != nil {
+			return fmt.Errorf("failed to write snapshot message: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *snapshotStore) Log(context string) ([]Revision, error) {
+	entries, err := os.ReadDir(s.contextDir(context))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read snapshots: %w", err)
+	}
+
+	var revisions []Revision
+	for _, entry := range entries {
+		created, err := time.Parse(snapshotIDTime, entry.Name())
+		if !entry.IsDir() || err != nil {
+			continue
+		}
+
+		message, _ := os.ReadFile(filepath.Join(s.contextDir(context), entry.Name(), snapshotMessage))
+		revisions = append(revisions, Revision{
+			ID:      entry.Name(),
+			Time:    created.Local(),
+			Message: strings.TrimSpace(string(message)),
+		})
+	}
+
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisions[i].ID > revisions[j].ID
+	})
+	return revisions, nil
+}
+
+// resolve finds the snapshot matching a revision or a unique prefix of one
+func (s *snapshotStore) resolve(context, rev string) (string, error) {
+	revisions, err := s.Log(context)
+	if err != nil {
+		return "", err
+	}
+
+	var match string
+	for _, revision := range revisions {
+		if strings.HasPrefix(revision.ID, rev) {
+			if match != "" {
+				return "", fmt.Errorf("%w: '%s' is ambiguous", ErrRevisionNotFound, rev)
+			}
+			match = revision.ID
+		}
+	}
+	if match == "" {
+		return "", fmt.Errorf("%w: %s", ErrRevisionNotFound, rev)
+	}
+
+	return filepath.Join(s.contextDir(context), match, snapshotFiles), nil
+}
+
+func (s *snapshotStore) Files(context, rev string) (map[string][]byte, error) {
+	dir, err := s.resolve(context, rev)
+	if err != nil {
+		return nil, err
+	}
+	return readFiles(dir)
+}
+
+func (s *snapshotStore) Restore(context, rev string) error {
+	dir, err := s.resolve(context, rev)
+	if err != nil {
+		return err
+	}
+
+	dst := filepath.Join(s.dir, context)
+	if err := os.RemoveAll(dst); err != nil {
+		return fmt.Errorf("failed to clear context directory: %w", err)
+	}
+	if err := config.CreateDir(dst); err != nil {
+		return fmt.Errorf("failed to create context directory: %w", err)
+	}
+	return config.CopyDir(dir, dst)
+}
+
+// readFiles reads every regular file below dir, keyed by relative path.
+// Sensitive files are left out.
+func readFiles(dir string) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil || config.IsSensitive(rel) {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = content
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read files: %w", err)
+	}
+	return files, nil
+}
+
+// sameFiles reports whether dir holds exactly the given files
+func sameFiles(files map[string][]byte, dir string) bool {
+	current, err := readFiles(dir)
+	if err != nil || len(current) != len(files) {
+		return false
+	}
+	for path, content := range files {
+		if string(current[path]) != string(content) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,143 @@
+// Package output prints command results for people or for scripts
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/template"
+
+	"github.com/1outres/cldenv/pkg/yamlenc"
+)
+
+// Format selects how a command prints its result
+type Format string
+
+const (
+	// Table is the human-readable output, the default
+	Table Format = "table"
+	// JSON prints the result as indented JSON
+	JSON Format = "json"
+	// YAML prints the result as YAML with the same keys as JSON
+	YAML Format = "yaml"
+	// Name prints one identifier per line, e.g. context names
+	Name Format = "name"
+)
+
+// Formats lists the supported formats
+var Formats = []Format{Table, JSON, YAML, Name}
+
+// ParseFormat checks a format given on the command line
+func ParseFormat(s string) (Format, error) {
+	for _, format := range Formats {
+		if string(format) == s {
+			return format, nil
+		}
+	}
+
+	names := make([]string, len(Formats))
+	for i, format := range Formats {
+		names[i] = string(format)
+	}
+	return "", fmt.Errorf("unknown output format '%s' (supported: %s)", s, strings.Join(names, ", "))
+}
+
+// Printer writes command results in the selected format
+type Printer struct {
+	w        io.Writer
+	format   Format
+	template *template.Template
+}
+
+// NewPrinter returns a printer for a format and an optional Go template. A
+// template replaces the format, so it can only be combined with table.
+func NewPrinter(w io.Writer, format, tmpl string) (*Printer, error) {
+	f, err := ParseFormat(format)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Printer{w: w, format: f}
+	if tmpl == "" {
+		return p, nil
+	}
+	if f != Table {
+		return nil, fmt.Errorf("--format-template cannot be combined with --output %s", f)
+	}
+
+	p.template, err = template.New("output").Funcs(funcs).Parse(tmpl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	return p, nil
+}
+
+// funcs are the functions available to templates in addition to the built-ins
+var funcs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// Human reports whether the result is printed for people. Decorative lines
+// such as hints and headings should only be printed then.
+func (p *Printer) Human() bool {
+	return p.format == Table && p.template == nil
+}
+
+// Print writes a result. value is encoded for json, yaml and templates, names
+// are printed for the name format and table prints the human-readable output.
+// Templates are applied to each element when value is a slice.
+func (p *Printer) Print(value any, names []string, table func()) error {
+	switch {
+	case p.template != nil:
+		return p.execute(value)
+	case p.format == JSON:
+		encoder := json.NewEncoder(p.w)
+		encoder.SetIndent("", "  ")
+		encoder.SetEscapeHTML(false)
+		return encoder.Encode(value)
+	case p.format == YAML:
+		data, err := yamlenc.Marshal(value)
+		if err != nil {
+			return err
+		}
+		_, err = p.w.Write(data)
+		return err
+	case p.format == Name:
+		for _, name := range names {
+			fmt.Fprintln(p.w, name)
+		}
+		return nil
+	default:
+		table()
+		return nil
+	}
+}
+
+// execute applies the template to value, or to each element of a slice
+func (p *Printer) execute(value any) error {
+	items := []any{value}
+	if v := reflect.ValueOf(value); v.Kind() == reflect.Slice {
+		items = make([]any, v.Len())
+		for i := range items {
+			items[i] = v.Index(i).Interface()
+		}
+	}
+
+	for _, item := range items {
+		var out strings.Builder
+		if err := p.template.Execute(&out, item); err != nil {
+			return fmt.Errorf("failed to execute template: %w", err)
+		}
+		// Terminate each item's output like the other formats do
+		fmt.Fprintln(p.w, strings.TrimSuffix(out.String(), "\n"))
+	}
+	return nil
+}
@@ -0,0 +1,253 @@
+package schema
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Severity classifies a validation issue
+type Severity string
+
+const (
+	// Error issues make a file unusable by Claude Code
+	Error Severity = "error"
+	// Warning issues are suspicious but do not prevent loading the file
+	Warning Severity = "warning"
+)
+
+// Issue is a problem found while validating a file
+type Issue struct {
+	Severity Severity `json:"severity"`
+	// Path is the key path of the offending value, empty for the whole document
+	Path    string `json:"path,omitempty"`
+	Message string `json:"message"`
+}
+
+func (i Issue) String() string {
+	if i.Path == "" {
+		return i.Message
+	}
+	return i.Path + ": " + i.Message
+}
+
+//go:embed settings.schema.json
+var settingsSchema []byte
+
+// node is a subset of JSON Schema: type, properties, additionalProperties,
+// items and enum, plus $ref to a named definition and deprecationMessage
+type node struct {
+	Type        typeList         `json:"type"`
+	Properties  map[string]*node `json:"properties"`
+	Items       *node            `json:"items"`
+	Enum        []any            `json:"enum"`
+	Ref         string           `json:"$ref"`
+	Deprecated  string           `json:"deprecationMessage"`
+	Definitions map[string]*node `json:"definitions"`
+
+	// closed objects reject keys that are not listed in Properties
+	closed     bool
+	additional *node
+}
+
+func (n *node) UnmarshalJSON(data []byte) error {
+	type plain node
+	var raw struct {
+		plain
+		AdditionalProperties json.RawMessage `json:"additionalProperties"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*n = node(raw.plain)
+
+	switch strings.TrimSpace(string(raw.AdditionalProperties)) {
+	case "", "true":
+	case "false":
+		n.closed = true
+	default:
+		n.additional = &node{}
+		return json.Unmarshal(raw.AdditionalProperties, n.additional)
+	}
+	return nil
+}
+
+// typeList accepts a single type name or a list of them
+type typeList []string
+
+func (t *typeList) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = typeList{single}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(t))
+}
+
+var settings = mustParse(settingsSchema)
+
+func mustParse(data []byte) *node {
+	var root node
+	if err := json.Unmarshal(data, &root); err != nil {
+		panic(fmt.Sprintf("schema: invalid embedded schema: %v", err))
+	}
+	return &root
+}
+
+// ValidateSettings checks a settings.json document. Syntax errors and
+// documents that are not an object are errors; unknown or deprecated keys and
+// values of the wrong type are warnings.
+func ValidateSettings(data []byte) []Issue {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return []Issue{{Severity: Error, Message: "file is empty, which is not valid JSON (use {})"}}
+	}
+
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return []Issue{{Severity: Error, Message: syntaxMessage(data, err)}}
+	}
+	if _, ok := doc.(map[string]any); !ok {
+		return []Issue{{Severity: Error, Message: fmt.Sprintf("expected an object, got %s", typeOf(doc))}}
+	}
+
+	var issues []Issue
+	settings.validate(settings, doc, "", &issues)
+	return issues
+}
+
+// HasErrors reports whether any issue is an error
+func HasErrors(issues []Issue) bool {
+	for _, issue := range issues {
+		if issue.Severity == Error {
+			return true
+		}
+	}
+	return false
+}
+
+// syntaxMessage describes a JSON syntax error with its line and column
+func syntaxMessage(data []byte, err error) string {
+	var syntaxErr *json.SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		return fmt.Sprintf("invalid JSON: %v", err)
+	}
+
+	offset := int(syntaxErr.Offset)
+	line := 1 + bytes.Count(data[:offset], []byte("\n"))
+	column := offset - bytes.LastIndexByte(data[:offset], '\n')
+	return fmt.Sprintf("invalid JSON at line %d, column %d: %v", line, column, err)
+}
+
+func (n *node) validate(root *node, v any, path string, issues *[]Issue) {
+	if n.Ref != "" {
+		def, ok := root.Definitions[n.Ref]
+		if !ok {
+			panic(fmt.Sprintf("schema: unknown definition %q", n.Ref))
+		}
+		n = def
+	}
+
+	if n.Deprecated != "" {
+		*issues = append(*issues, Issue{Severity: Warning, Path: path, Message: "deprecated, " + n.Deprecated})
+	}
+
+	if len(n.Type) > 0 && !n.accepts(v) {
+		*issues = append(*issues, Issue{
+			Severity: Warning,
+			Path:     path,
+			Message:  fmt.Sprintf("expected %s, got %s", strings.Join(n.Type, " or "), typeOf(v)),
+		})
+		return
+	}
+
+	if len(n.Enum) > 0 && !containsValue(n.Enum, v) {
+		allowed := make([]string, len(n.Enum))
+		for i, e := range n.Enum {
+			allowed[i] = fmt.Sprintf("%v", e)
+		}
+		*issues = append(*issues, Issue{
+			Severity: Warning,
+			Path:     path,
+			Message:  fmt.Sprintf("unexpected value %v, expected one of %s", v, strings.Join(allowed, ", ")),
+		})
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			child := k
+			if path != "" {
+				child = path + "." + k
+			}
+			switch {
+			case n.Properties[k] != nil:
+				n.Properties[k].validate(root, v[k], child, issues)
+			case n.additional != nil:
+				n.additional.validate(root, v[k], child, issues)
+			case n.closed:
+				*issues = append(*issues, Issue{Severity: Warning, Path: child, Message: "unknown key"})
+			}
+		}
+	case []any:
+		if n.Items == nil {
+			return
+		}
+		for i, e := range v {
+			n.Items.validate(root, e, fmt.Sprintf("%s[%d]", path, i), issues)
+		}
+	}
+}
+
+// accepts reports whether v has one of the node's types
+func (n *node) accepts(v any) bool {
+	actual := typeOf(v)
+	for _, t := range n.Type {
+		if t == actual || t == "number" && actual == "integer" {
+			return true
+		}
+	}
+	return false
+}
+
+// typeOf returns the JSON Schema type name of a decoded JSON value
+func typeOf(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+func containsValue(values []any, v any) bool {
+	for _, e := range values {
+		if reflect.DeepEqual(e, v) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,27 @@
+package dirwatch
+
+import (
+	"errors"
+)
+
+var (
+	ErrUnsupported = errors.New("directory watching is not supported on this platform")
+	ErrClosed      = errors.New("watcher closed")
+)
+
+// Op describes what happened to a directory entry
+type Op string
+
+const (
+	Create Op = "create"
+	Write  Op = "write"
+	Remove Op = "remove"
+	Rename Op = "rename"
+)
+
+// Event is a change to an entry of a watched directory
+type Event struct {
+	Dir  string
+	Name string
+	Op   Op
+}
@@ -0,0 +1,110 @@
+//go:build linux
+
+package dirwatch
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+const watchMask = syscall.IN_CREATE | syscall.IN_DELETE | syscall.IN_MOVED_FROM |
+	syscall.IN_MOVED_TO | syscall.IN_CLOSE_WRITE | syscall.IN_DONT_FOLLOW | syscall.IN_ONLYDIR
+
+// Watcher reports changes to the entries of a set of directories using inotify
+type Watcher struct {
+	file    *os.File
+	dirs    map[int32]string
+	pending []Event
+	buf     [64 * (syscall.SizeofInotifyEvent + syscall.NAME_MAX + 1)]byte
+}
+
+// New starts watching the given directories. Subdirectories are not watched.
+func New(dirs ...string) (*Watcher, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize inotify: %w", err)
+	}
+
+	// A non-blocking descriptor is handled by the runtime poller, so Close
+	// unblocks a pending Next
+	w := &Watcher{
+		file: os.NewFile(uintptr(fd), "inotify"),
+		dirs: make(map[int32]string),
+	}
+
+	for _, dir := range dirs {
+		wd, err := syscall.InotifyAddWatch(fd, dir, watchMask)
+		if err != nil {
+			w.Close()
+			return nil, fmt.Errorf("failed to watch %s: %w", dir, err)
+		}
+		w.dirs[int32(wd)] = dir
+	}
+
+	return w, nil
+}
+
+// Next blocks until the next event is available
+func (w *Watcher) Next() (Event, error) {
+	for len(w.pending) == 0 {
+		n, err := w.file.Read(w.buf[:])
+		if err != nil {
+			if errors.Is(err, os.ErrClosed) {
+				return Event{}, ErrClosed
+			}
+			return Event{}, fmt.Errorf("failed to read inotify events: %w", err)
+		}
+		w.parse(w.buf[:n])
+	}
+
+	event := w.pending[0]
+	w.pending = w.pending[1:]
+	return event, nil
+}
+
+// Close stops watching
+func (w *Watcher) Close() error {
+	return w.file.Close()
+}
+
+// parse decodes raw inotify events into pending events
+func (w *Watcher) parse(buf []byte) {
+	for offset := 0; offset+syscall.SizeofInotifyEvent <= len(buf); {
+		raw := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+		nameStart := offset + syscall.SizeofInotifyEvent
+		nameEnd := nameStart + int(raw.Len)
+		offset = nameEnd
+
+		dir, ok := w.dirs[raw.Wd]
+		if !ok || raw.Len == 0 || nameEnd > len(buf) {
+			continue
+		}
+
+		name := string(buf[nameStart:nameEnd])
+		for i := 0; i < len(name); i++ {
+			if name[i] == 0 {
+				name = name[:i]
+				break
+			}
+		}
+
+		var op Op
+		switch {
+		case raw.Mask&syscall.IN_CREATE != 0:
+			op = Create
+		case raw.Mask&syscall.IN_CLOSE_WRITE != 0:
+			op = Write
+		case raw.Mask&syscall.IN_DELETE != 0:
+			op = Remove
+		case raw.Mask&(syscall.IN_MOVED_FROM|syscall.IN_MOVED_TO) != 0:
+			op = Rename
+		default:
+			continue
+		}
+
+		w.pending = append(w.pending, Event{Dir: dir, Name: name, Op: op})
+	}
+}
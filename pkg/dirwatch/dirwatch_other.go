@@ -0,0 +1,21 @@
+//go:build !linux
+
+package dirwatch
+
+// Watcher reports changes to the entries of a set of directories
+type Watcher struct{}
+
+// New always fails on platforms without inotify
+func New(dirs ...string) (*Watcher, error) {
+	return nil, ErrUnsupported
+}
+
+// Next blocks until the next event is available
+func (w *Watcher) Next() (Event, error) {
+	return Event{}, ErrUnsupported
+}
+
+// Close stops watching
+func (w *Watcher) Close() error {
+	return nil
+}
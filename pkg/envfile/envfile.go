@@ -0,0 +1,125 @@
+package envfile
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Var is an environment variable
+type Var struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+var namePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Parse reads NAME=value lines. Blank lines and lines starting with # are
+// ignored, an "export " prefix is allowed, and values may be single quoted
+// (taken literally) or double quoted (with Go escapes such as \n). Values are
+// not expanded. Later assignments of a name replace earlier ones.
+func Parse(data []byte) ([]Var, error) {
+	var vars []Var
+	index := make(map[string]int)
+
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		name, value, ok := strings.Cut(line, "=")
+		name = strings.TrimSpace(name)
+		if !ok || !namePattern.MatchString(name) {
+			return nil, fmt.Errorf("line %d: expected NAME=value", i+1)
+		}
+
+		value, err := parseValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+
+		if j, ok := index[name]; ok {
+			vars[j].Value = value
+			continue
+		}
+		index[name] = len(vars)
+		vars = append(vars, Var{Name: name, Value: value})
+	}
+
+	return vars, nil
+}
+
+// parseValue unquotes a value
+func parseValue(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, "'"):
+		if len(value) < 2 || !strings.HasSuffix(value, "'") {
+			return "", fmt.Errorf("unterminated single quote")
+		}
+		return value[1 : len(value)-1], nil
+	case strings.HasPrefix(value, `"`):
+		unquoted, err := strconv.Unquote(value)
+		if err != nil {
+			return "", fmt.Errorf("invalid double-quoted value %s", value)
+		}
+		return unquoted, nil
+	default:
+		// Unquoted values end at a comment
+		if i := strings.Index(value, " #"); i >= 0 {
+			value = strings.TrimSpace(value[:i])
+		}
+		return value, nil
+	}
+}
+
+// Merge returns base with the variables of override added or replaced
+func Merge(base, override []Var) []Var {
+	merged := append([]Var(nil), base...)
+	index := make(map[string]int)
+	for i, v := range merged {
+		index[v.Name] = i
+	}
+
+	for _, v := range override {
+		if i, ok := index[v.Name]; ok {
+			merged[i] = v
+			continue
+		}
+		index[v.Name] = len(merged)
+		merged = append(merged, v)
+	}
+	return merged
+}
+
+// Shells lists the shells Export and Unset support
+var Shells = []string{"bash", "zsh", "fish"}
+
+// Export returns a command setting and exporting a variable in shell
+func Export(shell string, v Var) string {
+	if shell == "fish" {
+		return fmt.Sprintf("set -gx %s %s", v.Name, fishQuote(v.Value))
+	}
+	return fmt.Sprintf("export %s=%s", v.Name, posixQuote(v.Value))
+}
+
+// Unset returns a command removing a variable in shell
+func Unset(shell, name string) string {
+	if shell == "fish" {
+		return "set -e " + name
+	}
+	return "unset " + name
+}
+
+// posixQuote quotes a string for bash and zsh
+func posixQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// fishQuote quotes a string for fish, where backslashes escape in single quotes
+func fishQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return "'" + strings.ReplaceAll(s, "'", `\'`) + "'"
+}
@@ -0,0 +1,124 @@
+package envfile
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    []Var
+		wantErr bool
+	}{
+		{
+			name: "plain assignments",
+			data: "A=1\nB = two words\n",
+			want: []Var{{"A", "1"}, {"B", "two words"}},
+		},
+		{
+			name: "comments and blank lines",
+			data: "# comment\n\n  # indented comment\nA=1 # trailing\nB=a#b\n",
+			want: []Var{{"A", "1"}, {"B", "a#b"}},
+		},
+		{
+			name: "export prefix",
+			data: "export A=1\n  export B='x'\nexported=2\n",
+			want: []Var{{"A", "1"}, {"B", "x"}, {"exported", "2"}},
+		},
+		{
+			name: "single quotes are literal",
+			data: `A='$HOME \n # not a comment'`,
+			want: []Var{{"A", `$HOME \n # not a comment`}},
+		},
+		{
+			name: "double quotes take escapes",
+			data: `A="line\nnext \"quoted\" \\ # kept"`,
+			want: []Var{{"A", "line\nnext \"quoted\" \\ # kept"}},
+		},
+		{
+			name: "values are not expanded",
+			data: "A=${HOME}/bin\n",
+			want: []Var{{"A", "${HOME}/bin"}},
+		},
+		{
+			name: "empty values",
+			data: "A=\nB=''\nC=\"\"\n",
+			want: []Var{{"A", ""}, {"B", ""}, {"C", ""}},
+		},
+		{
+			name: "later assignments win in place",
+			data: "A=1\nB=2\nA=3\n",
+			want: []Var{{"A", "3"}, {"B", "2"}},
+		},
+		{
+			name: "value containing =",
+			data: "URL=https://example.com/?a=b\n",
+			want: []Var{{"URL", "https://example.com/?a=b"}},
+		},
+		{name: "missing =", data: "A\n", wantErr: true},
+		{name: "invalid name", data: "1A=x\n", wantErr: true},
+		{name: "space in name", data: "MY VAR=x\n", wantErr: true},
+		{name: "unterminated single quote", data: "A='x\n", wantErr: true},
+		{name: "lone single quote", data: "A='\n", wantErr: true},
+		{name: "unterminated double quote", data: "A=\"x\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrorLine(t *testing.T) {
+	_, err := Parse([]byte("A=1\n\nbad line\n"))
+	if err == nil || err.Error() != "line 3: expected NAME=value" {
+		t.Errorf("Parse() error = %v, want it to name line 3", err)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	base := []Var{{"A", "1"}, {"B", "2"}}
+	got := Merge(base, []Var{{"B", "3"}, {"C", "4"}})
+	want := []Var{{"A", "1"}, {"B", "3"}, {"C", "4"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Merge() = %q, want %q", got, want)
+	}
+	if base[1].Value != "2" {
+		t.Errorf("Merge() modified base: %q", base)
+	}
+}
+
+func TestExport(t *testing.T) {
+	tests := []struct {
+		shell string
+		value string
+		want  string
+	}{
+		{"bash", "plain", `export KEY='plain'`},
+		{"zsh", "it's $HOME", `export KEY='it'\''s $HOME'`},
+		{"bash", `back\slash`, `export KEY='back\slash'`},
+		{"fish", "plain", `set -gx KEY 'plain'`},
+		{"fish", "it's", `set -gx KEY 'it\'s'`},
+		{"fish", `back\slash`, `set -gx KEY 'back\\slash'`},
+	}
+	for _, tt := range tests {
+		if got := Export(tt.shell, Var{"KEY", tt.value}); got != tt.want {
+			t.Errorf("Export(%s, %q) = %s, want %s", tt.shell, tt.value, got, tt.want)
+		}
+	}
+
+	if got := Unset("bash", "KEY"); got != "unset KEY" {
+		t.Errorf("Unset(bash) = %s", got)
+	}
+	if got := Unset("fish", "KEY"); got != "set -e KEY" {
+		t.Errorf("Unset(fish) = %s", got)
+	}
+}
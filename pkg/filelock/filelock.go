@@ -0,0 +1,76 @@
+package filelock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrLocked = errors.New("lock is held by another process")
+)
+
+// pollInterval is how often a busy lock is retried
+const pollInterval = 50 * time.Millisecond
+
+// Lock is an advisory inter-process lock backed by a file
+type Lock struct {
+	path string
+	file *os.File
+}
+
+// Acquire takes the lock at path, waiting up to timeout for another process to release it.
+// The PID of the holder is written to the lock file so a busy lock can be reported.
+func Acquire(path string, timeout time.Duration) (*Lock, error) {
+	deadline := time.Now().Add(timeout)
+
+	for {
+		lock, err := tryAcquire(path)
+		if err == nil {
+			if err := lock.writePID(); err != nil {
+				lock.Release()
+				return nil, err
+			}
+			return lock, nil
+		}
+		if !errors.Is(err, ErrLocked) {
+			return nil, err
+		}
+
+		if time.Now().After(deadline) {
+			if pid := HolderPID(path); pid > 0 {
+				return nil, fmt.Errorf("%w (pid %d)", ErrLocked, pid)
+			}
+			return nil, err
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// HolderPID returns the PID recorded in the lock file, or 0 if unknown
+func HolderPID(path string) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0
+	}
+	return pid
+}
+
+// writePID records the current process as the holder of the lock
+func (l *Lock) writePID() error {
+	if err := l.file.Truncate(0); err != nil {
+		return fmt.Errorf("failed to write lock file: %w", err)
+	}
+	if _, err := l.file.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0); err != nil {
+		return fmt.Errorf("failed to write lock file: %w", err)
+	}
+	return nil
+}
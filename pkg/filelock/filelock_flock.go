@@ -0,0 +1,41 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package filelock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// tryAcquire takes an exclusive flock on path without blocking
+func tryAcquire(path string) (*Lock, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		file.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, ErrLocked
+		}
+		return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+	}
+
+	return &Lock{path: path, file: file}, nil
+}
+
+// Release releases the lock. The lock file is kept so other processes can keep using it.
+func (l *Lock) Release() error {
+	if err := l.file.Truncate(0); err != nil {
+		l.file.Close()
+		return fmt.Errorf("failed to clear lock file: %w", err)
+	}
+	if err := syscall.Flock(int(l.file.Fd()), syscall.LOCK_UN); err != nil {
+		l.file.Close()
+		return fmt.Errorf("failed to unlock %s: %w", l.path, err)
+	}
+	return l.file.Close()
+}
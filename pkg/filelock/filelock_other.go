@@ -0,0 +1,30 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package filelock
+
+import (
+	"fmt"
+	"os"
+)
+
+// tryAcquire creates path exclusively; its existence marks the lock as held
+func tryAcquire(path string) (*Lock, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		if os.IsExist(err) {
+			return nil, ErrLocked
+		}
+		return nil, fmt.Errorf("failed to create lock file: %w", err)
+	}
+
+	return &Lock{path: path, file: file}, nil
+}
+
+// Release releases the lock by removing the lock file
+func (l *Lock) Release() error {
+	l.file.Close()
+	if err := os.Remove(l.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove lock file: %w", err)
+	}
+	return nil
+}
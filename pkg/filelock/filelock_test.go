@@ -0,0 +1,99 @@
+package filelock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAcquireRelease(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lock")
+
+	lock, err := Acquire(path, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pid := HolderPID(path); pid != os.Getpid() {
+		t.Errorf("HolderPID() = %d, want %d", pid, os.Getpid())
+	}
+	if err := lock.Release(); err != nil {
+		t.Fatal(err)
+	}
+	if pid := HolderPID(path); pid != 0 {
+		t.Errorf("HolderPID() after release = %d, want 0", pid)
+	}
+
+	// A released lock can be taken again
+	lock, err = Acquire(path, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lock.Release()
+}
+
+func TestAcquireTimeoutNamesHolder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lock")
+	held, err := Acquire(path, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer held.Release()
+
+	start := time.Now()
+	_, err = Acquire(path, 100*time.Millisecond)
+	if !errors.Is(err, ErrLocked) {
+		t.Fatalf("Acquire() error = %v, want %v", err, ErrLocked)
+	}
+	if want := fmt.Sprintf("(pid %d)", os.Getpid()); !strings.Contains(err.Error(), want) {
+		t.Errorf("Acquire() error = %q, want it to contain %q", err, want)
+	}
+	if waited := time.Since(start); waited < 100*time.Millisecond {
+		t.Errorf("Acquire() gave up after %v, before the timeout", waited)
+	}
+}
+
+func TestAcquireWaitsForRelease(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lock")
+	held, err := Acquire(path, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		held.Release()
+	}()
+
+	lock, err := Acquire(path, 5*time.Second)
+	if err != nil {
+		t.Fatalf("Acquire() did not get the released lock: %v", err)
+	}
+	lock.Release()
+}
+
+func TestHolderPID(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		content string
+		want    int
+	}{
+		{"1234\n", 1234},
+		{"", 0},
+		{"not a pid", 0},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(dir, "lock")
+		if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if got := HolderPID(path); got != tt.want {
+			t.Errorf("HolderPID(%q) = %d, want %d", tt.content, got, tt.want)
+		}
+	}
+	if got := HolderPID(filepath.Join(dir, "missing")); got != 0 {
+		t.Errorf("HolderPID(missing) = %d, want 0", got)
+	}
+}
@@ -0,0 +1,159 @@
+package jsondiff
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Kind is the kind of a change
+type Kind string
+
+const (
+	Added   Kind = "added"
+	Removed Kind = "removed"
+	Changed Kind = "changed"
+)
+
+// Change is a difference at a single key path
+type Change struct {
+	// Path locates the value, e.g. "permissions.allow[3]"
+	Path string `json:"path"`
+	Kind Kind   `json:"kind"`
+	Old  any    `json:"old"`
+	New  any    `json:"new"`
+}
+
+// plainKey matches object keys that need no quoting in a path
+var plainKey = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$-]*$`)
+
+// Diff returns the changes turning a into b, ordered by path. Objects are
+// compared key by key, so key order does not matter; arrays are compared
+// element by element.
+func Diff(a, b any) []Change {
+	var changes []Change
+	diff(a, b, "", &changes)
+	return changes
+}
+
+func diff(a, b any, path string, changes *[]Change) {
+	switch av := a.(type) {
+	case map[string]any:
+		bv, ok := b.(map[string]any)
+		if !ok {
+			break
+		}
+
+		keys := make(map[string]bool)
+		for k := range av {
+			keys[k] = true
+		}
+		for k := range bv {
+			keys[k] = true
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+
+		for _, k := range sorted {
+			child := joinKey(path, k)
+			before, inA := av[k]
+			after, inB := bv[k]
+			switch {
+			case !inA:
+				*changes = append(*changes, Change{Path: child, Kind: Added, New: after})
+			case !inB:
+				*changes = append(*changes, Change{Path: child, Kind: Removed, Old: before})
+			default:
+				diff(before, after, child, changes)
+			}
+		}
+		return
+	case []any:
+		bv, ok := b.([]any)
+		if !ok {
+			break
+		}
+
+		for i := 0; i < max(len(av), len(bv)); i++ {
+			child := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case i >= len(av):
+				*changes = append(*changes, Change{Path: child, Kind: Added, New: bv[i]})
+			case i >= len(bv):
+				*changes = append(*changes, Change{Path: child, Kind: Removed, Old: av[i]})
+			default:
+				diff(av[i], bv[i], child, changes)
+			}
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(a, b) {
+		*changes = append(*changes, Change{Path: path, Kind: Changed, Old: a, New: b})
+	}
+}
+
+// joinKey appends an object key to a path, quoting keys that are not plain
+func joinKey(path, key string) string {
+	if !plainKey.MatchString(key) {
+		return path + "[" + strconv.Quote(key) + "]"
+	}
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// Format renders a change as a single line, e.g. `~ model: "a" -> "b"`
+func Format(c Change) string {
+	path := c.Path
+	if path == "" {
+		path = "(root)"
+	}
+
+	switch c.Kind {
+	case Added:
+		return fmt.Sprintf("+ %s: %s", path, formatValue(c.New))
+	case Removed:
+		return fmt.Sprintf("- %s: %s", path, formatValue(c.Old))
+	default:
+		return fmt.Sprintf("~ %s: %s -> %s", path, formatValue(c.Old), formatValue(c.New))
+	}
+}
+
+// formatValue renders a JSON value compactly
+func formatValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return strconv.Quote(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		parts := make([]string, 0, len(keys))
+		for _, k := range keys {
+			parts = append(parts, strconv.Quote(k)+": "+formatValue(v[k]))
+		}
+		return "{" + strings.Join(parts, ", ") + "}"
+	case []any:
+		parts := make([]string, 0, len(v))
+		for _, e := range v {
+			parts = append(parts, formatValue(e))
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,98 @@
+package jsondiff
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// parse decodes a JSON document for table tests
+func parse(t *testing.T, doc string) any {
+	t.Helper()
+	var v any
+	if err := json.Unmarshal([]byte(doc), &v); err != nil {
+		t.Fatalf("invalid test document %s: %v", doc, err)
+	}
+	return v
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		a    string
+		b    string
+		want []string
+	}{
+		{
+			name: "equal documents",
+			a:    `{"model": "opus", "env": {"A": "1"}}`,
+			b:    `{"env": {"A": "1"}, "model": "opus"}`,
+			want: nil,
+		},
+		{
+			name: "keys added, removed and changed in path order",
+			a:    `{"model": "opus", "theme": "dark", "env": {"A": "1"}}`,
+			b:    `{"model": "sonnet", "env": {"A": "1", "B": "2"}}`,
+			want: []string{`+ env.B: "2"`, `~ model: "opus" -> "sonnet"`, `- theme: "dark"`},
+		},
+		{
+			name: "array elements by index",
+			a:    `{"permissions": {"allow": ["Read", "Edit", "Bash(ls)", "Bash(git status)"]}}`,
+			b:    `{"permissions": {"allow": ["Read", "Write", "Bash(ls)", "Bash(git diff)", "WebFetch"]}}`,
+			want: []string{
+				`~ permissions.allow[1]: "Edit" -> "Write"`,
+				`~ permissions.allow[3]: "Bash(git status)" -> "Bash(git diff)"`,
+				`+ permissions.allow[4]: "WebFetch"`,
+			},
+		},
+		{
+			name: "removed array elements",
+			a:    `{"allow": ["Read", "Edit"]}`,
+			b:    `{"allow": ["Read"]}`,
+			want: []string{`- allow[1]: "Edit"`},
+		},
+		{
+			name: "objects inside arrays",
+			a:    `{"hooks": [{"command": "lint", "timeout": 10}]}`,
+			b:    `{"hooks": [{"command": "lint", "timeout": 30}]}`,
+			want: []string{`~ hooks[0].timeout: 10 -> 30`},
+		},
+		{
+			name: "keys that are not plain are quoted",
+			a:    `{"env": {"MY VAR": "1"}, "mcpServers": {"my.server": {"url": "a"}}}`,
+			b:    `{"env": {"MY VAR": "2"}, "mcpServers": {"my.server": {"url": "b"}}}`,
+			want: []string{`~ env["MY VAR"]: "1" -> "2"`, `~ mcpServers["my.server"].url: "a" -> "b"`},
+		},
+		{
+			name: "type changes replace the whole value",
+			a:    `{"permissions": {"allow": ["Read"]}}`,
+			b:    `{"permissions": "none"}`,
+			want: []string{`~ permissions: {"allow": ["Read"]} -> "none"`},
+		},
+		{
+			name: "root values",
+			a:    `[1]`,
+			b:    `{"a": null}`,
+			want: []string{`~ (root): [1] -> {"a": null}`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, change := range Diff(parse(t, tt.a), parse(t, tt.b)) {
+				got = append(got, Format(change))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffChange(t *testing.T) {
+	changes := Diff(parse(t, `{"allow": ["Read"]}`), parse(t, `{"allow": ["Read", "Bash"]}`))
+	want := []Change{{Path: "allow[1]", Kind: Added, New: "Bash"}}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("Diff() = %+v, want %+v", changes, want)
+	}
+}
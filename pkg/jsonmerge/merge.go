@@ -0,0 +1,184 @@
+package jsonmerge
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrInvalidStrategy = errors.New("invalid array merge strategy")
+)
+
+// ArrayStrategy controls how arrays present on both sides are merged
+type ArrayStrategy string
+
+const (
+	// Replace uses the overriding array as is
+	Replace ArrayStrategy = "replace"
+	// Append appends the overriding elements that are not already present
+	Append ArrayStrategy = "append"
+)
+
+// Options configures a merge
+type Options struct {
+	// Arrays is the strategy used for arrays without a path-specific strategy
+	Arrays ArrayStrategy
+	// Paths maps dotted key paths (e.g. "permissions.allow") to a strategy
+	Paths map[string]ArrayStrategy
+}
+
+// ParseStrategy parses an array merge strategy, defaulting to Replace
+func ParseStrategy(s string) (ArrayStrategy, error) {
+	switch ArrayStrategy(s) {
+	case "", Replace:
+		return Replace, nil
+	case Append:
+		return Append, nil
+	default:
+		return "", fmt.Errorf("%w: '%s' (expected 'replace' or 'append')", ErrInvalidStrategy, s)
+	}
+}
+
+// Merge deep merges override into base and returns the result.
+// Objects are merged key by key, arrays according to the options and any
+// other value in override replaces the one in base. Neither input is modified.
+func Merge(base, override any, opts Options) any {
+	return merge(base, override, opts, nil)
+}
+
+func merge(base, override any, opts Options, path []string) any {
+	switch o := override.(type) {
+	case map[string]any:
+		b, ok := base.(map[string]any)
+		if !ok {
+			return override
+		}
+		result := make(map[string]any, len(b)+len(o))
+		for k, v := range b {
+			result[k] = v
+		}
+		for k, v := range o {
+			if existing, ok := result[k]; ok {
+				result[k] = merge(existing, v, opts, append(path, k))
+			} else {
+				result[k] = v
+			}
+		}
+		return result
+	case []any:
+		b, ok := base.([]any)
+		if !ok || opts.strategy(path) != Append {
+			return override
+		}
+		result := append([]any{}, b...)
+		for _, v := range o {
+			if !contains(result, v) {
+				result = append(result, v)
+			}
+		}
+		return result
+	default:
+		return override
+	}
+}
+
+// strategy returns the array strategy for a key path
+func (o Options) strategy(path []string) ArrayStrategy {
+	if s, ok := o.Paths[strings.Join(path, ".")]; ok {
+		return s
+	}
+	if o.Arrays == "" {
+		return Replace
+	}
+	return o.Arrays
+}
+
+func contains(values []any, v any) bool {
+	for _, existing := range values {
+		if reflect.DeepEqual(existing, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// ThreeWay merges the changes local and remote made to base. Objects are
+// merged key by key; any other value changed differently on both sides is a
+// conflict, reported by its dotted key path. Conflicting keys keep the local
+// value. A nil document means the document does not exist.
+func ThreeWay(base, local, remote any) (any, []string) {
+	result, _, conflicts := threeWay(value{base, base != nil}, value{local, local != nil}, value{remote, remote != nil}, nil)
+	return result.v, conflicts
+}
+
+// value is a JSON value that may be absent
+type value struct {
+	v  any
+	ok bool
+}
+
+func (a value) equal(b value) bool {
+	return a.ok == b.ok && (!a.ok || reflect.DeepEqual(a.v, b.v))
+}
+
+func threeWay(base, local, remote value, path []string) (value, bool, []string) {
+	switch {
+	case local.equal(remote):
+		return local, true, nil
+	case base.equal(local):
+		return remote, true, nil
+	case base.equal(remote):
+		return local, true, nil
+	}
+
+	l, lok := local.v.(map[string]any)
+	r, rok := remote.v.(map[string]any)
+	b, bok := base.v.(map[string]any)
+	if !local.ok || !remote.ok || !lok || !rok || (base.ok && !bok) {
+		return local, false, []string{formatPath(path)}
+	}
+
+	keys := make(map[string]bool)
+	for k := range l {
+		keys[k] = true
+	}
+	for k := range r {
+		keys[k] = true
+	}
+	for k := range b {
+		keys[k] = true
+	}
+
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	merged := make(map[string]any)
+	var conflicts []string
+	for _, k := range sorted {
+		bv, bhas := b[k]
+		lv, lhas := l[k]
+		rv, rhas := r[k]
+
+		result, _, keyConflicts := threeWay(value{bv, bhas}, value{lv, lhas}, value{rv, rhas}, append(path, k))
+		conflicts = append(conflicts, keyConflicts...)
+		if result.ok {
+			merged[k] = result.v
+		}
+	}
+
+	return value{merged, true}, len(conflicts) == 0, conflicts
+}
+
+// formatPath formats a key path for conflict reports
+func formatPath(path []string) string {
+	if len(path) == 0 {
+		return "(root)"
+	}
+	return strings.Join(path, ".")
+}
@@ -0,0 +1,212 @@
+package jsonmerge
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// parse decodes a JSON document for table tests
+func parse(t *testing.T, doc string) any {
+	t.Helper()
+	if doc == "" {
+		return nil
+	}
+	var v any
+	if err := json.Unmarshal([]byte(doc), &v); err != nil {
+		t.Fatalf("invalid test document %s: %v", doc, err)
+	}
+	return v
+}
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     string
+		override string
+		opts     Options
+		want     string
+	}{
+		{
+			name:     "objects merge key by key",
+			base:     `{"model": "opus", "env": {"A": "1", "B": "2"}}`,
+			override: `{"env": {"B": "3", "C": "4"}}`,
+			want:     `{"model": "opus", "env": {"A": "1", "B": "3", "C": "4"}}`,
+		},
+		{
+			name:     "scalars and type changes replace",
+			base:     `{"a": {"nested": true}, "b": 1}`,
+			override: `{"a": "flat", "b": null}`,
+			want:     `{"a": "flat", "b": null}`,
+		},
+		{
+			name:     "arrays replace by default",
+			base:     `{"permissions": {"allow": ["Read", "Edit"]}}`,
+			override: `{"permissions": {"allow": ["Bash"]}}`,
+			want:     `{"permissions": {"allow": ["Bash"]}}`,
+		},
+		{
+			name:     "append skips elements already present",
+			base:     `{"permissions": {"allow": ["Read", "Edit"]}}`,
+			override: `{"permissions": {"allow": ["Edit", "Bash"]}}`,
+			opts:     Options{Arrays: Append},
+			want:     `{"permissions": {"allow": ["Read", "Edit", "Bash"]}}`,
+		},
+		{
+			name:     "path strategy overrides the default",
+			base:     `{"permissions": {"allow": ["Read"], "deny": ["Write"]}}`,
+			override: `{"permissions": {"allow": ["Bash"], "deny": ["Fetch"]}}`,
+			opts:     Options{Paths: map[string]ArrayStrategy{"permissions.allow": Append}},
+			want:     `{"permissions": {"allow": ["Read", "Bash"], "deny": ["Fetch"]}}`,
+		},
+		{
+			name:     "path strategy can replace under append",
+			base:     `{"permissions": {"allow": ["Read"], "deny": ["Write"]}}`,
+			override: `{"permissions": {"allow": ["Bash"], "deny": ["Fetch"]}}`,
+			opts:     Options{Arrays: Append, Paths: map[string]ArrayStrategy{"permissions.deny": Replace}},
+			want:     `{"permissions": {"allow": ["Read", "Bash"], "deny": ["Fetch"]}}`,
+		},
+		{
+			name:     "append compares objects deeply",
+			base:     `{"hooks": [{"command": "lint"}]}`,
+			override: `{"hooks": [{"command": "lint"}, {"command": "test"}]}`,
+			opts:     Options{Arrays: Append},
+			want:     `{"hooks": [{"command": "lint"}, {"command": "test"}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base, override := parse(t, tt.base), parse(t, tt.override)
+			got := Merge(base, override, tt.opts)
+			if want := parse(t, tt.want); !reflect.DeepEqual(got, want) {
+				t.Errorf("Merge() = %v, want %v", got, want)
+			}
+			if !reflect.DeepEqual(base, parse(t, tt.base)) {
+				t.Errorf("Merge() modified base: %v", base)
+			}
+		})
+	}
+}
+
+func TestParseStrategy(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    ArrayStrategy
+		wantErr bool
+	}{
+		{"", Replace, false},
+		{"replace", Replace, false},
+		{"append", Append, false},
+		{"prepend", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseStrategy(tt.in)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("ParseStrategy(%q) = %q, %v", tt.in, got, err)
+		}
+		if tt.wantErr && !errors.Is(err, ErrInvalidStrategy) {
+			t.Errorf("ParseStrategy(%q) error = %v, want %v", tt.in, err, ErrInvalidStrategy)
+		}
+	}
+}
+
+func TestThreeWay(t *testing.T) {
+	tests := []struct {
+		name      string
+		base      string
+		local     string
+		remote    string
+		want      string
+		conflicts []string
+	}{
+		{
+			name:   "changes to different keys merge",
+			base:   `{"model": "opus", "env": {"A": "1"}}`,
+			local:  `{"model": "sonnet", "env": {"A": "1"}}`,
+			remote: `{"model": "opus", "env": {"A": "1", "B": "2"}}`,
+			want:   `{"model": "sonnet", "env": {"A": "1", "B": "2"}}`,
+		},
+		{
+			name:   "same change on both sides",
+			base:   `{"model": "opus"}`,
+			local:  `{"model": "sonnet"}`,
+			remote: `{"model": "sonnet"}`,
+			want:   `{"model": "sonnet"}`,
+		},
+		{
+			name:   "removed key stays removed",
+			base:   `{"model": "opus", "theme": "dark"}`,
+			local:  `{"model": "opus"}`,
+			remote: `{"model": "sonnet", "theme": "dark"}`,
+			want:   `{"model": "sonnet"}`,
+		},
+		{
+			name:      "different changes to the same key",
+			base:      `{"model": "opus", "env": {"A": "1"}}`,
+			local:     `{"model": "sonnet", "env": {"A": "2"}}`,
+			remote:    `{"model": "haiku", "env": {"A": "3"}}`,
+			want:      `{"model": "sonnet", "env": {"A": "2"}}`,
+			conflicts: []string{"env.A", "model"},
+		},
+		{
+			name:      "add/add with different values",
+			base:      `{}`,
+			local:     `{"model": "sonnet"}`,
+			remote:    `{"model": "haiku"}`,
+			want:      `{"model": "sonnet"}`,
+			conflicts: []string{"model"},
+		},
+		{
+			name:   "add/add of objects merges their keys",
+			local:  `{"env": {"A": "1"}}`,
+			remote: `{"env": {"B": "2"}}`,
+			want:   `{"env": {"A": "1", "B": "2"}}`,
+		},
+		{
+			name:      "delete/modify of a key",
+			base:      `{"env": {"A": "1"}}`,
+			local:     `{}`,
+			remote:    `{"env": {"A": "2"}}`,
+			want:      `{}`,
+			conflicts: []string{"env"},
+		},
+		{
+			name:      "arrays conflict as a whole",
+			base:      `{"allow": ["Read"]}`,
+			local:     `{"allow": ["Read", "Edit"]}`,
+			remote:    `{"allow": ["Read", "Bash"]}`,
+			want:      `{"allow": ["Read", "Edit"]}`,
+			conflicts: []string{"allow"},
+		},
+		{
+			name:   "nil is a removed document",
+			base:   `{"model": "opus"}`,
+			remote: `{"model": "opus"}`,
+			want:   "",
+		},
+		{
+			name:   "document added on one side",
+			remote: `{"model": "opus"}`,
+			want:   `{"model": "opus"}`,
+		},
+		{
+			name:      "removed document modified on the other side",
+			base:      `{"model": "opus"}`,
+			remote:    `{"model": "sonnet"}`,
+			want:      "",
+			conflicts: []string{"(root)"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, conflicts := ThreeWay(parse(t, tt.base), parse(t, tt.local), parse(t, tt.remote))
+			if want := parse(t, tt.want); !reflect.DeepEqual(got, want) {
+				t.Errorf("ThreeWay() = %v, want %v", got, want)
+			}
+			if !reflect.DeepEqual(conflicts, tt.conflicts) {
+				t.Errorf("ThreeWay() conflicts = %q, want %q", conflicts, tt.conflicts)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package passphrase
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+var (
+	ErrNoTerminal = errors.New("no terminal to read a passphrase from")
+)
+
+// Read prompts for a passphrase on the controlling terminal without echoing
+// it, so it works while stdin and stdout are redirected
+func Read(prompt string) (string, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return "", ErrNoTerminal
+	}
+	defer tty.Close()
+
+	if err := stty(tty, "-echo"); err != nil {
+		return "", fmt.Errorf("failed to disable echo: %w", err)
+	}
+	defer func() {
+		stty(tty, "echo")
+		fmt.Fprintln(tty)
+	}()
+
+	fmt.Fprint(tty, prompt)
+	line, err := bufio.NewReader(tty).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// stty changes a setting of the terminal
+func stty(tty *os.File, setting string) error {
+	cmd := exec.Command("stty", setting)
+	cmd.Stdin = tty
+	return cmd.Run()
+}
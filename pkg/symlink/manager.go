@@ -11,18 +11,28 @@ var (
 	ErrSymlinkFailed = errors.New("symlink operation failed")
 )
 
-// CreateSymlink creates a symbolic link from src to dst
+// CreateSymlink creates a symbolic link from src to dst.
+// The link is created under a temporary name and renamed over dst, so dst
+// is replaced atomically and never observed missing.
 func CreateSymlink(src, dst string) error {
-	// Remove existing file/symlink if it exists
-	if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("%w: failed to remove existing file: %v", ErrSymlinkFailed, err)
+	tmp := fmt.Sprintf("%s.tmp-%d", dst, os.Getpid())
+
+	// Remove a temporary link left behind by an interrupted run
+	if err := os.Remove(tmp); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("%w: failed to remove stale temporary link: %v", ErrSymlinkFailed, err)
 	}
 
 	// Create the symlink
-	if err := os.Symlink(src, dst); err != nil {
+	if err := os.Symlink(src, tmp); err != nil {
 		return fmt.Errorf("%w: failed to create symlink: %v", ErrSymlinkFailed, err)
 	}
 
+	// Replace existing file/symlink
+	if err := os.Rename(tmp, dst); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("%w: failed to replace existing file: %v", ErrSymlinkFailed, err)
+	}
+
 	return nil
 }
 
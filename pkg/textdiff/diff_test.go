@@ -0,0 +1,85 @@
+package textdiff
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// numbered returns lines 1..n, replacing some of them
+func numbered(n int, replace map[int]string) string {
+	var sb strings.Builder
+	for i := 1; i <= n; i++ {
+		line, ok := replace[i]
+		if !ok {
+			line = strconv.Itoa(i)
+		}
+		sb.WriteString(line + "\n")
+	}
+	return sb.String()
+}
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name string
+		a    string
+		b    string
+		want string
+	}{
+		{
+			name: "equal",
+			a:    "same\n",
+			b:    "same\n",
+			want: "",
+		},
+		{
+			name: "distant changes get separate hunks",
+			a:    numbered(20, nil),
+			b:    numbered(20, map[int]string{5: "five", 16: "sixteen"}),
+			want: "@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n" +
+				"@@ -13,7 +13,7 @@\n 13\n 14\n 15\n-16\n+sixteen\n 17\n 18\n 19\n",
+		},
+		{
+			name: "close changes share a hunk",
+			a:    numbered(10, nil),
+			b:    numbered(10, map[int]string{4: "four", 8: "eight"}),
+			want: "@@ -1,10 +1,10 @@\n 1\n 2\n 3\n-4\n+four\n 5\n 6\n 7\n-8\n+eight\n 9\n 10\n",
+		},
+		{
+			name: "added lines",
+			a:    "a\nc\n",
+			b:    "a\nb\nc\n",
+			want: "@@ -1,2 +1,3 @@\n a\n+b\n c\n",
+		},
+		{
+			name: "single line ranges omit the count",
+			a:    "a\n",
+			b:    "b\n",
+			want: "@@ -1 +1 @@\n-a\n+b\n",
+		},
+		{
+			name: "new file",
+			a:    "",
+			b:    "x\ny\n",
+			want: "@@ -0,0 +1,2 @@\n+x\n+y\n",
+		},
+		{
+			name: "removed file",
+			a:    "x\ny\n",
+			b:    "",
+			want: "@@ -1,2 +0,0 @@\n-x\n-y\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Unified("a/CLAUDE.md", "b/CLAUDE.md", tt.a, tt.b)
+			want := tt.want
+			if want != "" {
+				want = "--- a/CLAUDE.md\n+++ b/CLAUDE.md\n" + want
+			}
+			if got != want {
+				t.Errorf("Unified() =\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
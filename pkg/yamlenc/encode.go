@@ -0,0 +1,182 @@
+// Package yamlenc writes JSON-compatible values as YAML.
+//
+// Values are first encoded with encoding/json, so struct tags, omitempty and
+// custom marshalers apply exactly as they do for JSON output, and the YAML
+// keeps the key order of the JSON document.
+package yamlenc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// node is a decoded JSON value that remembers the order of object keys
+type node struct {
+	// kind is one of '{', '[' or 0 for scalars
+	kind   byte
+	keys   []string
+	values []*node
+	// scalar is the YAML representation of a scalar value
+	scalar string
+}
+
+// Marshal returns the YAML encoding of v
+func Marshal(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	root, err := decode(decoder)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	switch {
+	case root.kind != 0 && len(root.values) > 0:
+		writeBlock(&buf, root, 0)
+	case root.kind == '{':
+		buf.WriteString("{}\n")
+	case root.kind == '[':
+		buf.WriteString("[]\n")
+	default:
+		buf.WriteString(root.scalar + "\n")
+	}
+	return buf.Bytes(), nil
+}
+
+// decode reads the next JSON value from decoder
+func decode(decoder *json.Decoder) (*node, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := token.(type) {
+	case json.Delim:
+		n := &node{kind: byte(t)}
+		for decoder.More() {
+			if n.kind == '{' {
+				key, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+				n.keys = append(n.keys, key.(string))
+			}
+			value, err := decode(decoder)
+			if err != nil {
+				return nil, err
+			}
+			n.values = append(n.values, value)
+		}
+		// Consume the closing delimiter
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+		return n, nil
+	case nil:
+		return &node{scalar: "null"}, nil
+	case bool:
+		return &node{scalar: strconv.FormatBool(t)}, nil
+	case json.Number:
+		return &node{scalar: t.String()}, nil
+	case string:
+		return &node{scalar: quote(t)}, nil
+	default:
+		return nil, fmt.Errorf("unexpected JSON token %v", token)
+	}
+}
+
+// writeBlock writes the entries of a non-empty object or array in block style
+func writeBlock(w io.Writer, n *node, indent int) {
+	pad := strings.Repeat("  ", indent)
+	for i, value := range n.values {
+		prefix := pad + "- "
+		if n.kind == '{' {
+			prefix = pad + quote(n.keys[i]) + ":"
+		}
+		writeEntry(w, prefix, value, indent, n.kind == '[')
+	}
+}
+
+// writeEntry writes one value after its key or list dash
+func writeEntry(w io.Writer, prefix string, value *node, indent int, inList bool) {
+	switch {
+	case value.kind == 0:
+		fmt.Fprintf(w, "%s%s\n", strings.TrimSuffix(prefix, " ")+" ", value.scalar)
+	case len(value.values) == 0 && value.kind == '{':
+		fmt.Fprintf(w, "%s {}\n", strings.TrimSuffix(prefix, " "))
+	case len(value.values) == 0:
+		fmt.Fprintf(w, "%s []\n", strings.TrimSuffix(prefix, " "))
+	case inList && value.kind == '{':
+		// Start the object on the dash line: "- key: value"
+		var buf bytes.Buffer
+		writeBlock(&buf, value, indent+1)
+		fmt.Fprint(w, prefix+strings.TrimPrefix(buf.String(), strings.Repeat("  ", indent+1)))
+	case inList:
+		fmt.Fprintln(w, strings.TrimSuffix(prefix, " "))
+		writeBlock(w, value, indent+1)
+	case value.kind == '[':
+		// Sequences under a key are conventionally not indented further
+		fmt.Fprintln(w, prefix)
+		writeBlock(w, value, indent)
+	default:
+		fmt.Fprintln(w, prefix)
+		writeBlock(w, value, indent+1)
+	}
+}
+
+// quote returns s as a plain scalar if YAML reads it back as the same string,
+// otherwise as a double-quoted scalar
+func quote(s string) string {
+	if isPlain(s) {
+		return s
+	}
+
+	// JSON string escapes are valid in YAML double-quoted scalars
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// isPlain reports whether s can be written without quotes
+func isPlain(s string) bool {
+	if s == "" || strings.TrimSpace(s) != s {
+		return false
+	}
+
+	// Strings that would be read back as another type
+	switch strings.ToLower(s) {
+	case "null", "~", "true", "false", "yes", "no", "on", "off", "y", "n", ".inf", "-.inf", "+.inf", ".nan":
+		return false
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return false
+	}
+	// Numbers in other notations, dates and times all start with a digit
+	if s[0] >= '0' && s[0] <= '9' {
+		return false
+	}
+
+	if strings.ContainsAny(s[:1], "-?:,[]{}#&*!|>'\"%@`") {
+		return false
+	}
+	if strings.Contains(s, ": ") || strings.Contains(s, " #") || strings.HasSuffix(s, ":") {
+		return false
+	}
+	for _, r := range s {
+		if r < ' ' || r == 0x7f {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,136 @@
+package yamlenc
+
+import (
+	"testing"
+)
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"opus", "opus"},
+		{"two words", "two words"},
+		{"Bash(git status)", "Bash(git status)"},
+		{"a:b", "a:b"},
+		{"http://example.com/#x", "http://example.com/#x"},
+		{"", `""`},
+		{" padded", `" padded"`},
+		{"yes", `"yes"`},
+		{"No", `"No"`},
+		{"on", `"on"`},
+		{"null", `"null"`},
+		{"~", `"~"`},
+		{"1e3", `"1e3"`},
+		{"42", `"42"`},
+		{"-1", `"-1"`},
+		{".inf", `".inf"`},
+		{"2024-01-02", `"2024-01-02"`},
+		{"0x1F", `"0x1F"`},
+		{"key: value", `"key: value"`},
+		{"trailing:", `"trailing:"`},
+		{"a #comment", `"a #comment"`},
+		{"#comment", `"#comment"`},
+		{"- item", `"- item"`},
+		{"*alias", `"*alias"`},
+		{"{flow}", `"{flow}"`},
+		{"'single'", `"'single'"`},
+		{`say "hi"`, `say "hi"`},
+		{`"quoted"`, `"\"quoted\""`},
+		{"line\nbreak", `"line\nbreak"`},
+		{"tab\there", `"tab\there"`},
+		{"<html> & more", `<html> & more`},
+		{"@at", `"@at"`},
+	}
+	for _, tt := range tests {
+		if got := quote(tt.in); got != tt.want {
+			t.Errorf("quote(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	type server struct {
+		Name string   `json:"name"`
+		Args []string `json:"args,omitempty"`
+	}
+	type doc struct {
+		Active  string            `json:"active"`
+		Count   int               `json:"count"`
+		Ratio   float64           `json:"ratio"`
+		Enabled bool              `json:"enabled"`
+		Missing *string           `json:"missing"`
+		Tags    []string          `json:"tags"`
+		Env     map[string]string `json:"env"`
+		Servers []server          `json:"servers"`
+		Matrix  [][]int           `json:"matrix"`
+		Empty   map[string]string `json:"empty"`
+		None    []string          `json:"none"`
+	}
+
+	got, err := Marshal(doc{
+		Active:  "yes",
+		Count:   3,
+		Ratio:   0.5,
+		Enabled: true,
+		Tags:    []string{"work", "1e3"},
+		Env:     map[string]string{"B": "2", "A": "x: y"},
+		Servers: []server{{Name: "git", Args: []string{"--stdio"}}, {Name: "fs"}},
+		Matrix:  [][]int{{1, 2}},
+		Empty:   map[string]string{},
+		None:    []string{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `active: "yes"
+count: 3
+ratio: 0.5
+enabled: true
+missing: null
+tags:
+- work
+- "1e3"
+env:
+  A: "x: y"
+  B: "2"
+servers:
+- name: git
+  args:
+  - "--stdio"
+- name: fs
+matrix:
+-
+  - 1
+  - 2
+empty: {}
+none: []
+`
+	if string(got) != want {
+		t.Errorf("Marshal() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestMarshalTopLevel(t *testing.T) {
+	tests := []struct {
+		in   any
+		want string
+	}{
+		{"on", "\"on\"\n"},
+		{12, "12\n"},
+		{nil, "null\n"},
+		{map[string]int{}, "{}\n"},
+		{[]string{}, "[]\n"},
+		{[]string{"a"}, "- a\n"},
+	}
+	for _, tt := range tests {
+		got, err := Marshal(tt.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("Marshal(%#v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}